@@ -0,0 +1,36 @@
+package authkit
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireAudience returns a Gin middleware that checks the already-validated
+// token's audience contains at least one of the specified values. This must be
+// applied AFTER AuthN and lets a single AuthN be narrowed per route group
+// (e.g. a "/partner" group expecting a different project) without re-parsing.
+// It requires TokenModeJWT: session-mode claims carry no audience, so every
+// request would be rejected. Failures use AuthN's 401 body with error_code
+// ErrCodeAudienceMismatch.
+func RequireAudience(aud ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if cl := GetClaims(c); cl != nil {
+			for _, want := range aud {
+				for _, got := range cl.Audience {
+					if got == want {
+						c.Next()
+						return
+					}
+				}
+			}
+		}
+
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error":      fmt.Sprintf("token audience mismatch — requires one of: %s", strings.Join(aud, ", ")),
+			"error_code": ErrCodeAudienceMismatch,
+		})
+	}
+}
@@ -1,21 +1,86 @@
 package authkit
 
 import (
+	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// Machine-readable values of the "error_code" field in AuthN's 401 responses.
+// Frontends can use these to tell an expired session (prompt a silent refresh)
+// from a request that was never authenticated (redirect to login).
+const (
+	ErrCodeTokenMissing     = "token_missing"
+	ErrCodeTokenExpired     = "token_expired"
+	ErrCodeTokenNotYetValid = "token_not_yet_valid"
+	ErrCodeTokenInvalid     = "token_invalid"
+	ErrCodeAudienceMismatch = "audience_mismatch"
+	ErrCodeTokenRevoked     = "token_revoked"
+)
+
 // AuthN returns a Gin middleware that validates Zitadel JWT access tokens.
 // It extracts the Bearer token from the Authorization header (or "token" query
 // parameter for WebSocket upgrades), validates it against the JWKS endpoint,
 // and stores the parsed claims in the Gin context.
+// It is a convenience wrapper around NewAuthN that panics on error, so
+// misconfiguration stops startup.
 func AuthN(cfg Config) gin.HandlerFunc {
-	jwks := NewJWKSCache(cfg.IssuerURL + "/oauth/v2/keys")
+	h, err := NewAuthN(cfg)
+	if err != nil {
+		panic(err)
+	}
+	return h
+}
+
+// NewAuthN is like AuthN but runs cfg.Validate and reports configuration
+// problems as an error instead of panicking, so apps can handle them at
+// startup. With Config.WarmJWKS or Config.FailClosedOnJWKS it also fetches the
+// JWKS up front; only the latter turns a failed fetch into an error.
+func NewAuthN(cfg Config) (gin.HandlerFunc, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	var keys KeyProvider
+	var sessions *sessionValidator
+	if cfg.TokenMode == TokenModeSession {
+		sessions = newSessionValidator(cfg)
+	} else {
+		keys = keyProviderFromConfig(cfg)
+		if jwks, ok := keys.(*JWKSCache); ok && (cfg.FailClosedOnJWKS || cfg.WarmJWKS) {
+			if err := jwks.warm(context.Background()); err != nil {
+				if cfg.FailClosedOnJWKS {
+					return nil, fmt.Errorf("authkit: JWKS unavailable at startup: %w", err)
+				}
+				log.Printf("[authkit] JWKS warm-up failed, will retry on first request: %v", err)
+			}
+		}
+	}
+
+	var cache *tokenCache
+	if cfg.TokenCacheTTL > 0 && sessions == nil {
+		cache = newTokenCache(cfg.TokenCacheTTL)
+	}
+
+	sessionIDHeader := cfg.SessionIDHeader
+	if sessionIDHeader == "" {
+		sessionIDHeader = DefaultSessionIDHeader
+	}
+
+	requestIDHeader := cfg.RequestIDHeader
+	if requestIDHeader == "" {
+		requestIDHeader = DefaultRequestIDHeader
+	}
+
+	tokenOpts := tokenOptionsFromConfig(cfg)
 
 	skipSet := make(map[string]bool, len(cfg.SkipPaths))
 	for _, p := range cfg.SkipPaths {
@@ -24,135 +89,467 @@ func AuthN(cfg Config) gin.HandlerFunc {
 
 	log.Printf("[authkit] Initialized AuthN middleware (issuer=%s, audience=%s, skip=%d paths)",
 		cfg.IssuerURL, cfg.Audience, len(cfg.SkipPaths))
+	if cfg.DebugAuthz {
+		log.Printf("[authkit] WARNING: DebugAuthz is enabled — 403 responses will expose user roles")
+	}
+
+	if cfg.ReportOnly {
+		log.Printf("[authkit] WARNING: ReportOnly is enabled — invalid tokens are logged but NOT rejected")
+	}
+
+	// reject aborts with 401, or in ReportOnly mode records the failure and
+	// lets the request through unauthenticated.
+	reject := func(c *gin.Context, code, msg string, err error) {
+		if cfg.ReportOnly {
+			log.Printf("[authkit] report-only: %s %s would be rejected (%s, request_id=%s): %v",
+				c.Request.Method, c.Request.URL.Path, code, RequestID(c), err)
+			c.Set(authErrorKey, err)
+			c.Next()
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error":      msg,
+			"error_code": code,
+		})
+	}
+
+	// revalidate re-verifies a token whose claims came from the cache, for
+	// ForceFreshAuth. It reports whether the request may proceed.
+	revalidate := func(c *gin.Context, tokenStr string) bool {
+		claims, err := authenticateJWT(c.Request.Context(), tokenStr, cfg, keys)
+		if err == nil {
+			err = checkRevoked(c.Request.Context(), cfg, claims)
+		}
+		if err != nil {
+			// Drop the cached claims so ReportOnly lets the request through unauthenticated
+			SetClaims(c, nil)
+			code, msg := failureResponse(err)
+			reject(c, code, msg, err)
+			return false
+		}
+		cache.put(tokenStr, claims)
+		enrichClaims(c, cfg, claims)
+		SetClaims(c, claims)
+		c.Set(cacheHitKey, false)
+		return true
+	}
 
 	return func(c *gin.Context) {
-		// Skip configured paths
-		if skipSet[c.FullPath()] {
+		// Record the correlation ID first so skipped routes and downstream
+		// logs share it
+		requestID := c.GetHeader(requestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = newRequestID()
+		}
+		c.Set(requestIDKey, requestID)
+
+		// Skip configured paths, matching either the route pattern
+		// (e.g. "/api/:version/health") or the concrete request path
+		if skipSet[c.FullPath()] || skipSet[c.Request.URL.Path] {
 			c.Next()
 			return
 		}
 
 		// Extract token from Authorization header or query param (WebSocket fallback)
-		tokenStr := extractToken(c)
+		tokenStr := extractToken(c, tokenOpts)
 		if tokenStr == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": "missing or invalid Authorization header",
-			})
+			reject(c, ErrCodeTokenMissing, "missing or invalid Authorization header", errMissingToken)
 			return
 		}
 
-		// Parse and validate the JWT
-		token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
-			// Verify signing method
-			if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		// Validate the credential and build claims
+		var claims *Claims
+		var err error
+		cacheHit := false
+		switch {
+		case sessions != nil:
+			claims, err = sessions.validate(c.Request.Context(), c.GetHeader(sessionIDHeader), tokenStr)
+		case cache != nil:
+			if claims, cacheHit = cache.get(tokenStr); !cacheHit {
+				claims, err = authenticateJWT(c.Request.Context(), tokenStr, cfg, keys)
+				if err == nil {
+					cache.put(tokenStr, claims)
+				}
+			} else {
+				c.Set(freshAuthKey, func(c *gin.Context) bool { return revalidate(c, tokenStr) })
 			}
-
-			// Get the key ID from the token header
-			kid, ok := token.Header["kid"].(string)
-			if !ok {
-				return nil, fmt.Errorf("missing kid in token header")
-			}
-
-			// Fetch the public key from JWKS cache
-			key, err := jwks.GetKey(kid)
-			if err != nil {
-				return nil, err
-			}
-			return key, nil
-		},
-			jwt.WithIssuer(cfg.IssuerURL),
-			jwt.WithValidMethods([]string{"RS256"}),
-		)
-
-		if err != nil || !token.Valid {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": "invalid or expired token",
-			})
+		default:
+			claims, err = authenticateJWT(c.Request.Context(), tokenStr, cfg, keys)
+		}
+		c.Set(cacheHitKey, cacheHit)
+		if err == nil {
+			// Checked on cache hits too, so a revocation takes effect immediately
+			err = checkRevoked(c.Request.Context(), cfg, claims)
+		}
+		if err != nil {
+			code, msg := failureResponse(err)
+			reject(c, code, msg, err)
 			return
 		}
 
-		// Also validate audience if configured
-		if cfg.Audience != "" {
-			if err := validateAudience(token, cfg.Audience); err != nil {
-				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-					"error": "token audience mismatch",
-				})
-				return
-			}
+		enrichClaims(c, cfg, claims)
+		SetClaims(c, claims)
+		if cfg.DebugAuthz {
+			c.Set(debugAuthzKey, true)
+		}
+		if cfg.ScopeRolesToOrg {
+			c.Set(orgScopedRolesKey, true)
 		}
+		c.Next()
+	}, nil
+}
 
-		// Extract claims into our struct
-		mapClaims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": "invalid token claims",
-			})
-			return
+// enrichClaims runs cfg.EnrichClaims on cl. A hook that changes OrgID has
+// established the org itself, so it is no longer treated as inferred.
+func enrichClaims(c *gin.Context, cfg Config, cl *Claims) {
+	if cfg.EnrichClaims == nil {
+		return
+	}
+	orgID := cl.OrgID
+	cfg.EnrichClaims(c, cl)
+	if cl.OrgID != orgID {
+		cl.orgInferred = false
+	}
+}
+
+// authenticateJWT validates a JWT access token (signature, issuer, expiry and,
+// if configured, audience) and returns its claims.
+func authenticateJWT(ctx context.Context, tokenStr string, cfg Config, keys KeyProvider) (*Claims, error) {
+	token, err := parseToken(ctx, tokenStr, cfg, keys)
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, ErrInvalidToken
+	}
+
+	// Also validate audience if configured
+	if want := cfg.audiences(); len(want) > 0 {
+		if err := validateAudience(token, want, cfg.AudienceMatchMode); err != nil {
+			return nil, err
 		}
+	}
+
+	// Extract claims into our struct
+	mapClaims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, errInvalidClaims
+	}
+	claims := newClaims(mapClaims)
+	if cfg.MergeProjectRoles {
+		mergeProjectRoles(claims)
+	}
+	return claims, nil
+}
+
+// failureResponse maps a validation error to the error_code and message
+// AuthN returns in its 401 body.
+func failureResponse(err error) (code, msg string) {
+	switch {
+	case errors.Is(err, jwt.ErrTokenExpired), errors.Is(err, errSessionExpired):
+		return ErrCodeTokenExpired, "invalid or expired token"
+	case errors.Is(err, jwt.ErrTokenNotValidYet):
+		// nbf is in the future, typically IdP clock skew; clients may retry shortly
+		return ErrCodeTokenNotYetValid, "token not yet valid"
+	case errors.Is(err, errAudienceMalformed):
+		return ErrCodeAudienceMismatch, "token audience claim missing or malformed"
+	case errors.Is(err, errAudienceMismatch):
+		return ErrCodeAudienceMismatch, "token audience mismatch"
+	case errors.Is(err, errTokenRevoked):
+		return ErrCodeTokenRevoked, "token has been revoked"
+	case errors.Is(err, errInvalidClaims):
+		return ErrCodeTokenInvalid, "invalid token claims"
+	default:
+		return ErrCodeTokenInvalid, "invalid or expired token"
+	}
+}
 
-		claims := &Claims{
-			Sub:       getStringClaim(mapClaims, "sub"),
-			Email:     getStringClaim(mapClaims, "email"),
-			OrgID:     getStringClaim(mapClaims, "urn:zitadel:iam:org:id"),
-			OrgDomain: getStringClaim(mapClaims, "urn:zitadel:iam:user:resourceowner:primary_domain"),
+// parseToken verifies the JWT signature against the key provider and checks
+// the issuer. ctx bounds any JWKS fetch triggered while resolving the signing key.
+func parseToken(ctx context.Context, tokenStr string, cfg Config, keys KeyProvider) (*jwt.Token, error) {
+	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
+		// Verify signing method
+		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
 
-		// Extract project roles
-		if roles, ok := mapClaims["urn:zitadel:iam:org:project:roles"].(map[string]interface{}); ok {
-			claims.Roles = roles
+		// Get the key ID from the token header
+		kid, ok := token.Header["kid"].(string)
+		if !ok {
+			return nil, fmt.Errorf("missing kid in token header")
 		}
 
-		// Fallback: extract org ID from roles claim if not present as a top-level claim.
-		// Zitadel embeds the org ID as the key inside each role grant, e.g.:
-		//   "urn:zitadel:iam:org:project:roles": { "user": { "<orgID>": "domain" } }
-		if claims.OrgID == "" && claims.Roles != nil {
-			claims.OrgID = extractOrgIDFromRoles(claims.Roles)
+		// Fetch the public key from the JWKS cache or configured provider
+		key, err := lookupKey(ctx, keys, kid)
+		if err != nil {
+			return nil, err
 		}
+		return key, nil
+	},
+		jwt.WithValidMethods([]string{"RS256"}),
+	)
+	if err != nil {
+		return token, err
+	}
 
-		SetClaims(c, claims)
-		c.Next()
+	// Issuer is checked here rather than with jwt.WithIssuer so that trailing
+	// slash and scheme/host case differences don't cause spurious rejections.
+	if !cfg.SkipIssuerCheck {
+		iss, _ := token.Claims.GetIssuer()
+		if normalizeIssuer(iss) != normalizeIssuer(cfg.IssuerURL) {
+			return token, fmt.Errorf("%w: got %q", jwt.ErrTokenInvalidIssuer, iss)
+		}
 	}
+	return token, nil
 }
 
-// extractToken gets the JWT from the Authorization header or "token" query param.
-func extractToken(c *gin.Context) string {
-	// Try Authorization header first
-	authHeader := c.GetHeader("Authorization")
-	if authHeader != "" && strings.HasPrefix(authHeader, "Bearer ") {
-		return strings.TrimPrefix(authHeader, "Bearer ")
+// normalizeIssuer canonicalizes an issuer URL for comparison: the trailing
+// slash is dropped and the scheme and host are lower-cased.
+func normalizeIssuer(iss string) string {
+	iss = strings.TrimRight(iss, "/")
+	u, err := url.Parse(iss)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return iss
 	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	return u.String()
+}
+
+// DefaultJWKSPath is Zitadel's JWKS endpoint path, relative to the issuer.
+const DefaultJWKSPath = "/oauth/v2/keys"
 
-	// Fallback: query parameter (for WebSocket connections where browsers
-	// cannot set custom headers on the upgrade request)
-	if token := c.Query("token"); token != "" {
-		return token
+// JWKSURLFromIssuer returns the Zitadel JWKS URL for an issuer, tolerating a
+// trailing slash on the issuer.
+func JWKSURLFromIssuer(issuerURL string) string {
+	return joinURL(issuerURL, DefaultJWKSPath)
+}
+
+// joinURL joins base and path with exactly one slash between them.
+func joinURL(base, path string) string {
+	return strings.TrimRight(base, "/") + "/" + strings.TrimLeft(path, "/")
+}
+
+// newJWKSCacheFromConfig builds the JWKS cache used by AuthN and ValidateToken.
+func newJWKSCacheFromConfig(cfg Config) *JWKSCache {
+	var opts []JWKSOption
+	if cfg.KeyStore != nil {
+		opts = append(opts, WithKeyStore(cfg.KeyStore))
+	}
+	if len(cfg.JWKSHeaders) > 0 {
+		opts = append(opts, WithHeaders(cfg.JWKSHeaders))
+	}
+	if cfg.JWKSTimeout > 0 {
+		opts = append(opts, WithFetchTimeout(cfg.JWKSTimeout))
+	}
+	if cfg.MinRSAKeyBits > 0 {
+		opts = append(opts, WithMinRSAKeyBits(cfg.MinRSAKeyBits))
 	}
+	if cfg.JWKSKeyGracePeriod > 0 {
+		opts = append(opts, WithKeyGracePeriod(cfg.JWKSKeyGracePeriod))
+	}
+	jwksURL := cfg.JWKSURL
+	if jwksURL == "" {
+		jwksURL = JWKSURLFromIssuer(cfg.IssuerURL)
+	}
+	return NewJWKSCache(jwksURL, opts...)
+}
 
-	return ""
+// extractToken gets the JWT from the configured headers or "token" query param.
+func extractToken(c *gin.Context, opts []TokenOption) string {
+	return TokenFromRequest(c.Request, opts...)
+}
+
+// tokenOptionsFromConfig translates Config into TokenFromRequest options.
+func tokenOptionsFromConfig(cfg Config) []TokenOption {
+	var opts []TokenOption
+	if len(cfg.TokenHeaders) > 0 {
+		opts = append(opts, WithTokenHeaders(cfg.TokenHeaders...))
+	}
+	if cfg.DisableQueryToken {
+		opts = append(opts, WithTokenQueryParam(""))
+	}
+	return opts
+}
+
+const authErrorKey = "dromos_auth_error"
+
+// AuthError returns the validation error AuthN recorded for this request in
+// ReportOnly mode. Returns nil if the request authenticated successfully or
+// ReportOnly is off.
+func AuthError(c *gin.Context) error {
+	val, exists := c.Get(authErrorKey)
+	if !exists {
+		return nil
+	}
+	err, _ := val.(error)
+	return err
 }
 
-func validateAudience(token *jwt.Token, expectedAudience string) error {
+var (
+	errMissingToken = errors.New("missing bearer token")
+
+	// errAudienceMalformed is returned by validateAudience when the token has no
+	// usable "aud" claim, as opposed to one that simply doesn't match.
+	errAudienceMalformed = errors.New("audience claim missing or malformed")
+	errAudienceMismatch  = errors.New("audience mismatch")
+	errInvalidClaims     = errors.New("invalid claims type")
+)
+
+// ErrInvalidToken is returned (possibly wrapped) by ValidateToken for every
+// token it rejects. The underlying jwt error, such as jwt.ErrTokenExpired or
+// jwt.ErrTokenMalformed, is wrapped alongside it for use with errors.Is.
+var ErrInvalidToken = errors.New("invalid token")
+
+// validateAudience checks the token's "aud" claim against the configured
+// audiences: at least one must be present, or with AudienceMatchExact the
+// token's audiences must be exactly that set.
+func validateAudience(token *jwt.Token, expected []string, mode AudienceMatchMode) error {
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
 		return fmt.Errorf("invalid claims type")
 	}
 
 	// Zitadel may include audience as a string or array
+	var got []string
 	switch aud := claims["aud"].(type) {
 	case string:
-		if aud == expectedAudience {
-			return nil
+		if aud == "" {
+			return errAudienceMalformed
 		}
+		got = []string{aud}
 	case []interface{}:
+		if len(aud) == 0 {
+			return errAudienceMalformed
+		}
 		for _, a := range aud {
-			if s, ok := a.(string); ok && s == expectedAudience {
+			if s, ok := a.(string); ok {
+				got = append(got, s)
+			}
+		}
+	default:
+		// Missing, null, or an unexpected JSON type (number, object, ...)
+		return fmt.Errorf("%w: got %T", errAudienceMalformed, aud)
+	}
+
+	if mode == AudienceMatchExact {
+		if !sameStringSet(got, expected) {
+			return fmt.Errorf("%w: token audiences %q do not exactly match %q", errAudienceMismatch, got, expected)
+		}
+		return nil
+	}
+
+	for _, want := range expected {
+		for _, a := range got {
+			if a == want {
 				return nil
 			}
 		}
 	}
+	return fmt.Errorf("%w: none of %q found in token", errAudienceMismatch, expected)
+}
+
+// sameStringSet reports whether a and b contain the same values, ignoring
+// order and duplicates.
+func sameStringSet(a, b []string) bool {
+	setA := make(map[string]bool, len(a))
+	for _, v := range a {
+		setA[v] = true
+	}
+	setB := make(map[string]bool, len(b))
+	for _, v := range b {
+		if !setA[v] {
+			return false
+		}
+		setB[v] = true
+	}
+	return len(setA) == len(setB)
+}
+
+// getAudienceClaim returns the "aud" claim as a slice, whether Zitadel sent it
+// as a single string or an array.
+func getAudienceClaim(m jwt.MapClaims) []string {
+	switch aud := m["aud"].(type) {
+	case string:
+		return []string{aud}
+	case []interface{}:
+		out := make([]string, 0, len(aud))
+		for _, a := range aud {
+			if s, ok := a.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// getMetadataClaim decodes Zitadel's user metadata claim, a map of keys to
+// base64url-encoded values (standard base64 is also accepted). Values that
+// fail to decode are skipped and logged.
+func getMetadataClaim(m jwt.MapClaims) map[string]string {
+	raw, ok := m["urn:zitadel:iam:user:metadata"].(map[string]interface{})
+	if !ok || len(raw) == 0 {
+		return nil
+	}
+
+	out := make(map[string]string, len(raw))
+	for key, v := range raw {
+		encoded, ok := v.(string)
+		if !ok {
+			log.Printf("[authkit] Skipping metadata %q: value is %T, not a string", key, v)
+			continue
+		}
+		// Zitadel emits unpadded base64url; accept standard base64 as well.
+		decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(encoded, "="))
+		if err != nil {
+			decoded, err = base64.StdEncoding.DecodeString(encoded)
+		}
+		if err != nil {
+			log.Printf("[authkit] Skipping metadata %q: %v", key, err)
+			continue
+		}
+		out[key] = string(decoded)
+	}
+	return out
+}
+
+// getEmailsClaim collects addresses from the "email" claim (string or array)
+// followed by the "emails" claim, without duplicates.
+func getEmailsClaim(m jwt.MapClaims) []string {
+	var emails []string
+	seen := make(map[string]bool)
+	add := func(e string) {
+		if e != "" && !seen[e] {
+			seen[e] = true
+			emails = append(emails, e)
+		}
+	}
 
-	return fmt.Errorf("audience %q not found in token", expectedAudience)
+	add(getStringClaim(m, "email"))
+	for _, e := range getStringSliceClaim(m, "email") {
+		add(e)
+	}
+	for _, e := range getStringSliceClaim(m, "emails") {
+		add(e)
+	}
+	return emails
+}
+
+// getStringSliceClaim safely extracts a string array claim from JWT MapClaims,
+// ignoring any non-string elements.
+func getStringSliceClaim(m jwt.MapClaims, key string) []string {
+	arr, ok := m[key].([]interface{})
+	if !ok {
+		return nil
+	}
+	out := make([]string, 0, len(arr))
+	for _, v := range arr {
+		if s, ok := v.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
 }
 
 // getStringClaim safely extracts a string claim from JWT MapClaims.
@@ -163,9 +560,21 @@ func getStringClaim(m jwt.MapClaims, key string) string {
 	return ""
 }
 
-// KeyFunc returns a jwt.Keyfunc backed by the JWKS cache.
+// getBoolClaim returns a boolean claim, accepting the "true"/"false" strings
+// some IdPs send. Missing or malformed values are false.
+func getBoolClaim(m jwt.MapClaims, key string) bool {
+	switch v := m[key].(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true"
+	}
+	return false
+}
+
+// KeyFunc returns a jwt.Keyfunc backed by a key provider such as the JWKS cache.
 // This is useful for external code that needs to validate tokens directly.
-func KeyFunc(jwks *JWKSCache) jwt.Keyfunc {
+func KeyFunc(keys KeyProvider) jwt.Keyfunc {
 	return func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
@@ -174,35 +583,49 @@ func KeyFunc(jwks *JWKSCache) jwt.Keyfunc {
 		if !ok {
 			return nil, fmt.Errorf("missing kid in token header")
 		}
-		return jwks.GetKey(kid)
+		return keys.GetKey(kid)
 	}
 }
 
 // ValidateToken validates a raw JWT string and returns the claims.
 // Useful for validating tokens outside of HTTP middleware (e.g. WebSocket re-auth).
+// It applies the same checks as AuthN (issuer, audience, revocation) and
+// returns cfg.Validate's error unchanged for invalid configuration. Token
+// errors wrap ErrInvalidToken and, where available, the jwt package's error.
 func ValidateToken(tokenStr string, cfg Config) (*Claims, error) {
-	jwks := NewJWKSCache(cfg.IssuerURL + "/oauth/v2/keys")
+	return ValidateTokenContext(context.Background(), tokenStr, cfg)
+}
 
-	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		kid, ok := token.Header["kid"].(string)
-		if !ok {
-			return nil, fmt.Errorf("missing kid in token header")
-		}
-		key, err := jwks.GetKey(kid)
-		if err != nil {
+// ValidateTokenContext is like ValidateToken but propagates ctx into the JWKS
+// fetch, so callers can bound or cancel validation (e.g. in a WebSocket read loop).
+func ValidateTokenContext(ctx context.Context, tokenStr string, cfg Config) (*Claims, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	claims, err := authenticateJWT(ctx, tokenStr, cfg, keyProviderFromConfig(cfg))
+	if err == nil {
+		err = checkRevoked(ctx, cfg, claims)
+	}
+	if err != nil {
+		if errors.Is(err, ErrInvalidToken) {
 			return nil, err
 		}
-		return key, nil
-	},
-		jwt.WithIssuer(cfg.IssuerURL),
-		jwt.WithValidMethods([]string{"RS256"}),
-	)
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+	return claims, nil
+}
 
-	if err != nil || !token.Valid {
-		return nil, fmt.Errorf("invalid token: %w", err)
+// DecodeClaimsUnverified parses a JWT and returns its claims WITHOUT verifying
+// the signature, issuer, audience, or expiry.
+//
+// UNSAFE FOR AUTHORIZATION: anyone can forge a token that decodes cleanly.
+// This exists only so support tooling can inspect a token locally instead of
+// pasting it into third-party sites.
+func DecodeClaimsUnverified(tokenStr string) (*Claims, error) {
+	token, _, err := jwt.NewParser().ParseUnverified(tokenStr, jwt.MapClaims{})
+	if err != nil {
+		return nil, fmt.Errorf("malformed token: %w", err)
 	}
 
 	mapClaims, ok := token.Claims.(jwt.MapClaims)
@@ -210,34 +633,112 @@ func ValidateToken(tokenStr string, cfg Config) (*Claims, error) {
 		return nil, fmt.Errorf("invalid claims type")
 	}
 
+	return newClaims(mapClaims), nil
+}
+
+// newClaims builds a Claims value from the validated token's MapClaims.
+func newClaims(mapClaims jwt.MapClaims) *Claims {
 	claims := &Claims{
-		Sub:       getStringClaim(mapClaims, "sub"),
-		Email:     getStringClaim(mapClaims, "email"),
-		OrgID:     getStringClaim(mapClaims, "urn:zitadel:iam:org:id"),
-		OrgDomain: getStringClaim(mapClaims, "urn:zitadel:iam:user:resourceowner:primary_domain"),
+		Sub:               getStringClaim(mapClaims, "sub"),
+		Email:             getStringClaim(mapClaims, "email"),
+		PreferredUsername: getStringClaim(mapClaims, "preferred_username"),
+		EmailVerified:     getBoolClaim(mapClaims, "email_verified"),
+		PhoneNumber:       getStringClaim(mapClaims, "phone_number"),
+		PhoneVerified:     getBoolClaim(mapClaims, "phone_number_verified"),
+		OrgID:             getStringClaim(mapClaims, "urn:zitadel:iam:org:id"),
+		OrgDomain:         getStringClaim(mapClaims, "urn:zitadel:iam:user:resourceowner:primary_domain"),
+		Locale:            getStringClaim(mapClaims, "locale"),
+		JTI:               getStringClaim(mapClaims, "jti"),
+		AMR:               getStringSliceClaim(mapClaims, "amr"),
+		Audience:          getAudienceClaim(mapClaims),
+		Metadata:          getMetadataClaim(mapClaims),
+		Raw:               mapClaims,
 	}
+
+	// Federated identities may deliver email as an array or a separate "emails" claim
+	claims.Emails = getEmailsClaim(mapClaims)
+	if claims.Email == "" && len(claims.Emails) > 0 {
+		claims.Email = claims.Emails[0]
+	}
+
+	if exp, err := mapClaims.GetExpirationTime(); err == nil && exp != nil {
+		claims.ExpiresAt = exp.Time
+	}
+	if iat, err := mapClaims.GetIssuedAt(); err == nil && iat != nil {
+		claims.IssuedAt = iat.Time
+	}
+
+	// Token exchange (RFC 8693): "act": { "sub": "<actor>" }
+	if act, ok := mapClaims["act"].(map[string]interface{}); ok {
+		claims.ActorSub = getStringClaim(act, "sub")
+	}
+
+	// Authorized party; some token types carry client_id instead of azp
+	claims.ClientID = getStringClaim(mapClaims, "azp")
+	if claims.ClientID == "" {
+		claims.ClientID = getStringClaim(mapClaims, "client_id")
+	}
+
+	// Extract project roles
 	if roles, ok := mapClaims["urn:zitadel:iam:org:project:roles"].(map[string]interface{}); ok {
 		claims.Roles = roles
 	}
 
+	// Extract project-scoped roles ("urn:zitadel:iam:org:project:{projectId}:roles")
+	claims.ProjectRoles = extractProjectRoles(mapClaims)
+
+	// Fallback: extract org ID from roles claim if not present as a top-level claim.
+	// Zitadel embeds the org ID as the key inside each role grant, e.g.:
+	//   "urn:zitadel:iam:org:project:roles": { "user": { "<orgID>": "domain" } }
 	if claims.OrgID == "" && claims.Roles != nil {
 		claims.OrgID = extractOrgIDFromRoles(claims.Roles)
+		claims.orgInferred = claims.OrgID != ""
 	}
 
-	return claims, nil
+	return claims
 }
 
 // extractOrgIDFromRoles pulls the org ID from the Zitadel role grant structure.
-// Each role maps to { "<orgID>": "<domain>" }. Returns the first org ID found.
+// Each role maps to { "<orgID>": "<domain>" }. When grants span several orgs
+// the lexically smallest ID is returned, so the result is stable per token.
 func extractOrgIDFromRoles(roles map[string]interface{}) string {
+	best := ""
 	for _, grants := range roles {
 		grantsMap, ok := grants.(map[string]interface{})
 		if !ok {
 			continue
 		}
 		for orgID := range grantsMap {
-			return orgID
+			if best == "" || orgID < best {
+				best = orgID
+			}
 		}
 	}
-	return ""
+	return best
+}
+
+// extractProjectRoles collects every project-scoped roles claim, keyed by the
+// project ID embedded in the claim name.
+func extractProjectRoles(m jwt.MapClaims) map[string]map[string]interface{} {
+	const prefix, suffix = "urn:zitadel:iam:org:project:", ":roles"
+
+	var out map[string]map[string]interface{}
+	for key, val := range m {
+		if !strings.HasPrefix(key, prefix) || !strings.HasSuffix(key, suffix) {
+			continue
+		}
+		projectID := strings.TrimSuffix(strings.TrimPrefix(key, prefix), suffix)
+		if projectID == "" || strings.Contains(projectID, ":") {
+			continue
+		}
+		roles, ok := val.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if out == nil {
+			out = make(map[string]map[string]interface{})
+		}
+		out[projectID] = roles
+	}
+	return out
 }
@@ -0,0 +1,343 @@
+package authkit
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+const (
+	testIssuer = "https://issuer.example.com"
+	testKID    = "test-key"
+)
+
+var (
+	testKeyOnce sync.Once
+	testKey     *rsa.PrivateKey
+)
+
+// testSigningKey returns an RSA key shared by the tests in this package.
+func testSigningKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	testKeyOnce.Do(func() {
+		var err error
+		if testKey, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+			panic(err)
+		}
+	})
+	return testKey
+}
+
+// fixedKeys is a KeyProvider serving only the test signing key.
+type fixedKeys struct {
+	key *rsa.PublicKey
+}
+
+func (f fixedKeys) GetKey(kid string) (crypto.PublicKey, error) {
+	if kid != testKID {
+		return nil, fmt.Errorf("key %q not found", kid)
+	}
+	return f.key, nil
+}
+
+// testConfig returns a Config that validates tokens from signTestToken.
+func testConfig(t *testing.T) Config {
+	return Config{
+		IssuerURL: testIssuer,
+		Keys:      fixedKeys{key: &testSigningKey(t).PublicKey},
+	}
+}
+
+// signTestToken mints an RS256 token from the test issuer. claims override
+// the defaults (sub, iss, iat, exp one hour out).
+func signTestToken(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+	now := time.Now()
+	mc := jwt.MapClaims{
+		"sub": "user-1",
+		"iss": testIssuer,
+		"iat": now.Unix(),
+		"exp": now.Add(time.Hour).Unix(),
+	}
+	for k, v := range claims {
+		mc[k] = v
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, mc)
+	token.Header["kid"] = testKID
+	signed, err := token.SignedString(testSigningKey(t))
+	if err != nil {
+		t.Fatalf("signing test token: %v", err)
+	}
+	return signed
+}
+
+// serve runs a request through r and returns the recorder.
+func serve(r http.Handler, req *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// errorCode decodes the "error_code" field of a JSON error body.
+func errorCode(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON body %q: %v", w.Body.String(), err)
+	}
+	code, _ := body["error_code"].(string)
+	return code
+}
+
+func TestValidateAudience(t *testing.T) {
+	tests := []struct {
+		name     string
+		aud      interface{}
+		omit     bool
+		expected []string
+		mode     AudienceMatchMode
+		wantErr  error
+	}{
+		{name: "string match", aud: "proj", expected: []string{"proj"}},
+		{name: "string mismatch", aud: "other", expected: []string{"proj"}, wantErr: errAudienceMismatch},
+		{name: "empty string", aud: "", expected: []string{"proj"}, wantErr: errAudienceMalformed},
+		{name: "array match", aud: []interface{}{"client", "proj"}, expected: []string{"proj"}},
+		{name: "array mismatch", aud: []interface{}{"client", "other"}, expected: []string{"proj"}, wantErr: errAudienceMismatch},
+		{name: "empty array", aud: []interface{}{}, expected: []string{"proj"}, wantErr: errAudienceMalformed},
+		{name: "null", aud: nil, expected: []string{"proj"}, wantErr: errAudienceMalformed},
+		{name: "number", aud: float64(42), expected: []string{"proj"}, wantErr: errAudienceMalformed},
+		{name: "missing", omit: true, expected: []string{"proj"}, wantErr: errAudienceMalformed},
+		{name: "any configured audience", aud: []interface{}{"client"}, expected: []string{"proj", "client"}},
+
+		{name: "exact match", aud: []interface{}{"client", "proj"}, expected: []string{"proj", "client"}, mode: AudienceMatchExact},
+		{name: "exact match with duplicates", aud: []interface{}{"proj", "proj"}, expected: []string{"proj"}, mode: AudienceMatchExact},
+		{name: "exact extra audience", aud: []interface{}{"proj", "extra"}, expected: []string{"proj"}, mode: AudienceMatchExact, wantErr: errAudienceMismatch},
+		{name: "exact missing audience", aud: "proj", expected: []string{"proj", "client"}, mode: AudienceMatchExact, wantErr: errAudienceMismatch},
+		{name: "exact still rejects malformed", aud: "", expected: []string{"proj"}, mode: AudienceMatchExact, wantErr: errAudienceMalformed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := jwt.MapClaims{"sub": "user"}
+			if !tt.omit {
+				claims["aud"] = tt.aud
+			}
+			err := validateAudience(&jwt.Token{Claims: claims}, tt.expected, tt.mode)
+
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("validateAudience() error = %v, want nil", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("validateAudience() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSkipPaths(t *testing.T) {
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+
+	tests := []struct {
+		name     string
+		skip     []string
+		path     string
+		wantCode int
+	}{
+		{name: "route pattern", skip: []string{"/api/:version/health"}, path: "/api/v1/health", wantCode: http.StatusOK},
+		{name: "concrete path", skip: []string{"/api/v1/health"}, path: "/api/v1/health", wantCode: http.StatusOK},
+		{name: "concrete path for other version", skip: []string{"/api/v1/health"}, path: "/api/v2/health", wantCode: http.StatusUnauthorized},
+		{name: "unlisted sibling", skip: []string{"/api/:version/health"}, path: "/api/v1/status", wantCode: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(AuthN(Config{IssuerURL: testIssuer, SkipPaths: tt.skip}))
+			r.GET("/api/:version/health", ok)
+			r.GET("/api/:version/status", ok)
+
+			w := serve(r, httptest.NewRequest(http.MethodGet, tt.path, http.NoBody))
+			if w.Code != tt.wantCode {
+				t.Fatalf("GET %s: status = %d, want %d", tt.path, w.Code, tt.wantCode)
+			}
+			if tt.wantCode == http.StatusUnauthorized {
+				if code := errorCode(t, w); code != ErrCodeTokenMissing {
+					t.Errorf("error_code = %q, want %q", code, ErrCodeTokenMissing)
+				}
+			}
+		})
+	}
+}
+
+func TestAuthNNotYetValid(t *testing.T) {
+	r := gin.New()
+	r.Use(AuthN(testConfig(t)))
+	r.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		name     string
+		nbf      time.Duration
+		wantCode int
+	}{
+		{name: "nbf in the past", nbf: -time.Minute, wantCode: http.StatusOK},
+		{name: "nbf in the future", nbf: 10 * time.Minute, wantCode: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tok := signTestToken(t, jwt.MapClaims{"nbf": time.Now().Add(tt.nbf).Unix()})
+			req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+			req.Header.Set("Authorization", "Bearer "+tok)
+
+			w := serve(r, req)
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d (body %s)", w.Code, tt.wantCode, w.Body.String())
+			}
+			if tt.wantCode == http.StatusUnauthorized {
+				if code := errorCode(t, w); code != ErrCodeTokenNotYetValid {
+					t.Errorf("error_code = %q, want %q", code, ErrCodeTokenNotYetValid)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateTokenErrors(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.Audience = "mine"
+	cfg.RequireAudience = true
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{name: "valid", token: signTestToken(t, jwt.MapClaims{"aud": "mine"})},
+		{name: "expired", token: signTestToken(t, jwt.MapClaims{"aud": "mine", "exp": time.Now().Add(-time.Minute).Unix()}), wantErr: jwt.ErrTokenExpired},
+		{name: "malformed", token: "not-a-jwt", wantErr: jwt.ErrTokenMalformed},
+		{name: "wrong audience", token: signTestToken(t, jwt.MapClaims{"aud": "other"}), wantErr: errAudienceMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := ValidateToken(tt.token, cfg)
+			if tt.wantErr == nil {
+				if err != nil || claims == nil {
+					t.Fatalf("ValidateToken() = %v, %v; want claims", claims, err)
+				}
+				return
+			}
+			if claims != nil {
+				t.Errorf("ValidateToken() returned claims for a rejected token")
+			}
+			if !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("errors.Is(%v, ErrInvalidToken) = false", err)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("errors.Is(%v, %v) = false", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMetadataClaim(t *testing.T) {
+	tok := signTestToken(t, jwt.MapClaims{
+		"urn:zitadel:iam:user:metadata": map[string]interface{}{
+			"padded":   "dmFsdWU=",
+			"unpadded": "dmFsdWU",
+			"url":      "Pz8-Pz8_",
+			"std":      "Pz8+Pz8/",
+			"invalid":  "not base64!",
+		},
+	})
+	cl, err := DecodeClaimsUnverified(tok)
+	if err != nil {
+		t.Fatalf("DecodeClaimsUnverified: %v", err)
+	}
+
+	want := map[string]string{
+		"padded":   "value",
+		"unpadded": "value",
+		"url":      "??>???",
+		"std":      "??>???",
+	}
+	if len(cl.Metadata) != len(want) {
+		t.Errorf("Metadata = %q, want %q", cl.Metadata, want)
+	}
+	for k, v := range want {
+		if got := cl.Metadata[k]; got != v {
+			t.Errorf("Metadata[%q] = %q, want %q", k, got, v)
+		}
+	}
+}
+
+func TestValidateIssuerRequired(t *testing.T) {
+	keys := fixedKeys{key: &testSigningKey(t).PublicKey}
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{name: "fetched JWKS without issuer", cfg: Config{}, wantErr: true},
+		{name: "JWKSURL without issuer", cfg: Config{JWKSURL: "https://keys.example.com/jwks"}, wantErr: true},
+		{name: "Keys without issuer", cfg: Config{Keys: keys}, wantErr: true},
+		{name: "Keys with SkipIssuerCheck", cfg: Config{Keys: keys, SkipIssuerCheck: true}},
+		{name: "SkipIssuerCheck without Keys", cfg: Config{SkipIssuerCheck: true}, wantErr: true},
+		{name: "Keys with issuer", cfg: Config{IssuerURL: testIssuer, Keys: keys}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSkipIssuerCheck(t *testing.T) {
+	tok := signTestToken(t, jwt.MapClaims{"iss": "https://other.example.com"})
+	cfg := testConfig(t)
+	if _, err := ValidateToken(tok, cfg); err == nil {
+		t.Fatal("ValidateToken accepted a foreign issuer")
+	}
+
+	cfg.IssuerURL = ""
+	cfg.SkipIssuerCheck = true
+	if _, err := ValidateToken(tok, cfg); err != nil {
+		t.Fatalf("ValidateToken with SkipIssuerCheck: %v", err)
+	}
+}
+
+func TestValidatingHandlerSkipPaths(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.SkipPaths = []string{"/healthz"}
+	h := ValidatingHandler(cfg, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for path, want := range map[string]int{"/healthz": http.StatusOK, "/api": http.StatusUnauthorized} {
+		if w := serve(h, httptest.NewRequest(http.MethodGet, path, http.NoBody)); w.Code != want {
+			t.Errorf("GET %s: status = %d, want %d", path, w.Code, want)
+		}
+	}
+}
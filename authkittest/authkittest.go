@@ -0,0 +1,25 @@
+// Package authkittest provides helpers for unit-testing Gin handlers that
+// read authkit claims, kept out of the main package so production builds
+// don't link net/http/httptest.
+package authkittest
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	authkit "github.com/Prescott-Data/dromos-authkit"
+	"github.com/gin-gonic/gin"
+)
+
+// NewTestContext returns a Gin test context for a GET / request, already
+// authenticated with claims (unauthenticated if claims is nil). To inspect the
+// response, create the context with gin.CreateTestContext and call
+// authkit.InjectClaims instead.
+func NewTestContext(claims *authkit.Claims) *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+	if claims != nil {
+		authkit.InjectClaims(c, claims)
+	}
+	return c
+}
@@ -0,0 +1,29 @@
+package authkit
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireClient returns a Gin middleware that only admits tokens issued to one
+// of the given OAuth client IDs (the "azp" claim). Use it to keep third-party
+// tokens minted against the same Zitadel project away from first-party APIs.
+// Must be applied AFTER AuthN.
+func RequireClient(clientIDs ...string) gin.HandlerFunc {
+	allowed := make(map[string]bool, len(clientIDs))
+	for _, id := range clientIDs {
+		allowed[id] = true
+	}
+
+	return func(c *gin.Context) {
+		if id := ClientID(c); id != "" && allowed[id] {
+			c.Next()
+			return
+		}
+
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"error": "token was not issued to an allowed client",
+		})
+	}
+}
@@ -1,28 +1,132 @@
 package authkit
 
 import (
+	"sort"
+	"time"
+
 	"github.com/gin-gonic/gin"
 )
 
-const claimsKey = "dromos_auth_claims"
+const (
+	claimsKey         = "dromos_auth_claims"
+	orgScopedRolesKey = "dromos_auth_org_scoped_roles"
+)
 
 // Claims represents the validated JWT claims from Zitadel.
 type Claims struct {
 	// Sub is the Zitadel user ID.
 	Sub string `json:"sub"`
 
-	// Email is the user's email address.
+	// Email is the user's email address. When the token carries several, this
+	// is the first one.
 	Email string `json:"email"`
 
+	// Emails lists every address from the "email" claim (string or array) and
+	// any separate "emails" claim, as delivered by some federated IDPs.
+	Emails []string `json:"emails,omitempty"`
+
+	// EmailVerified reports whether the IdP has verified Email ("email_verified").
+	EmailVerified bool `json:"email_verified,omitempty"`
+
+	// PhoneNumber is the user's phone number ("phone_number"), present when the
+	// phone scope was requested.
+	PhoneNumber string `json:"phone_number,omitempty"`
+
+	// PhoneVerified reports whether PhoneNumber is verified ("phone_number_verified").
+	PhoneVerified bool `json:"phone_number_verified,omitempty"`
+
+	// PreferredUsername is the user's login name from the "preferred_username" claim.
+	PreferredUsername string `json:"preferred_username,omitempty"`
+
 	// OrgID is the Zitadel organization ID the user belongs to.
 	OrgID string `json:"urn:zitadel:iam:org:id"`
 
 	// OrgDomain is the primary domain of the user's resource owner organization.
 	OrgDomain string `json:"urn:zitadel:iam:user:resourceowner:primary_domain"`
 
+	// ActorSub is the subject of the "act" (actor) claim on token-exchange
+	// tokens: the service acting on behalf of Sub. Empty for normal tokens.
+	ActorSub string `json:"-"`
+
+	// ClientID is the OAuth client the token was issued to, taken from the
+	// "azp" (authorized party) claim, falling back to "client_id".
+	ClientID string `json:"azp,omitempty"`
+
+	// Locale is the user's preferred language (e.g. "en", "de-CH"), if present.
+	Locale string `json:"locale,omitempty"`
+
+	// JTI is the token's unique identifier ("jti"), used to look up revocations.
+	JTI string `json:"jti,omitempty"`
+
+	// AMR lists the authentication methods used to obtain the token
+	// (e.g. "pwd", "mfa", "otp"), taken from the "amr" claim.
+	AMR []string `json:"amr,omitempty"`
+
+	// ExpiresAt is the token's expiry ("exp"). Zero if the token has none.
+	ExpiresAt time.Time `json:"-"`
+
+	// IssuedAt is when the token was issued ("iat"). Zero if absent.
+	IssuedAt time.Time `json:"-"`
+
+	// Audience lists the token's "aud" values (Zitadel project and client IDs).
+	Audience []string `json:"aud,omitempty"`
+
 	// Roles maps role names to their grant details.
 	// The keys are role names (e.g. "admin", "editor").
 	Roles map[string]interface{} `json:"urn:zitadel:iam:org:project:roles"`
+
+	// ProjectRoles maps Zitadel project IDs to the roles granted in that
+	// project, taken from "urn:zitadel:iam:org:project:{projectId}:roles".
+	ProjectRoles map[string]map[string]interface{} `json:"-"`
+
+	// Metadata holds the user metadata Zitadel adds to the token (when enabled
+	// via an action) under "urn:zitadel:iam:user:metadata", base64-decoded.
+	Metadata map[string]string `json:"-"`
+
+	// orgInferred records that OrgID was guessed from the role grants because
+	// the token had no org ID claim. Org-scoped role checks don't trust it
+	// until EnrichClaims sets or confirms the org (see SetOrgID).
+	orgInferred bool
+
+	// Raw holds every claim from the validated token, including ones without a
+	// dedicated field (e.g. custom claims added by Zitadel actions).
+	Raw map[string]interface{} `json:"-"`
+}
+
+// Expired reports whether the token's expiry has passed. Tokens without an
+// exp claim never expire by this check.
+func (cl *Claims) Expired() bool {
+	return !cl.ExpiresAt.IsZero() && !time.Now().Before(cl.ExpiresAt)
+}
+
+// SetOrgID sets the active organization, marking it as established by the
+// caller rather than inferred from role grants. EnrichClaims hooks that
+// resolve the org should use it; calling SetOrgID(cl.OrgID) confirms an
+// inferred org for Config.ScopeRolesToOrg.
+func (cl *Claims) SetOrgID(id string) {
+	cl.OrgID = id
+	cl.orgInferred = false
+}
+
+// HasOrg reports whether the claims carry an established organization: one
+// from the "urn:zitadel:iam:org:id" claim, the session, or set by an
+// EnrichClaims hook (or SetOrgID). An OrgID AuthN only inferred from the role
+// grants doesn't count, so HasOrg can be false while OrgID is non-empty.
+// Machine tokens often have no org at all.
+func (cl *Claims) HasOrg() bool {
+	return cl.OrgID != "" && !cl.orgInferred
+}
+
+// ValidFor returns how long until the token expires. Returns zero if it has
+// already expired or carries no exp claim.
+func (cl *Claims) ValidFor() time.Duration {
+	if cl.ExpiresAt.IsZero() {
+		return 0
+	}
+	if d := time.Until(cl.ExpiresAt); d > 0 {
+		return d
+	}
+	return 0
 }
 
 // SetClaims stores validated claims in the Gin context.
@@ -62,6 +166,16 @@ func OrgID(c *gin.Context) string {
 	return ""
 }
 
+// HasOrg reports whether the authenticated request has an established
+// organization (see Claims.HasOrg). Returns false for unauthenticated requests,
+// org-less (e.g. machine) tokens, and orgs only inferred from role grants.
+func HasOrg(c *gin.Context) bool {
+	if cl := GetClaims(c); cl != nil {
+		return cl.HasOrg()
+	}
+	return false
+}
+
 // OrgDomain returns the authenticated user's organization primary domain.
 // Returns empty string if no org domain is available.
 func OrgDomain(c *gin.Context) string {
@@ -79,14 +193,114 @@ func Email(c *gin.Context) string {
 	return ""
 }
 
+// Username returns a human-readable identifier for the authenticated user,
+// e.g. for audit logs: the preferred_username claim, falling back to the email
+// and then the subject ID. Returns empty string if not authenticated.
+func Username(c *gin.Context) string {
+	cl := GetClaims(c)
+	if cl == nil {
+		return ""
+	}
+	switch {
+	case cl.PreferredUsername != "":
+		return cl.PreferredUsername
+	case cl.Email != "":
+		return cl.Email
+	default:
+		return cl.Sub
+	}
+}
+
+// ActorID returns the subject of the actor acting on the user's behalf
+// (token exchange). Returns empty string for tokens without an "act" claim.
+func ActorID(c *gin.Context) string {
+	if cl := GetClaims(c); cl != nil {
+		return cl.ActorSub
+	}
+	return ""
+}
+
+// ClientID returns the OAuth client ID the token was issued to.
+// Returns empty string if the token carries no azp/client_id claim.
+func ClientID(c *gin.Context) string {
+	if cl := GetClaims(c); cl != nil {
+		return cl.ClientID
+	}
+	return ""
+}
+
+// Locale returns the authenticated user's preferred locale from the token.
+// Returns empty string if the token carries no locale claim.
+func Locale(c *gin.Context) string {
+	if cl := GetClaims(c); cl != nil {
+		return cl.Locale
+	}
+	return ""
+}
+
+// Metadata returns the decoded value of the user metadata key from the token.
+// Returns empty string if the key is absent or could not be decoded.
+func Metadata(c *gin.Context, key string) string {
+	if cl := GetClaims(c); cl != nil {
+		return cl.Metadata[key]
+	}
+	return ""
+}
+
 // HasRole checks if the authenticated user has the specified role.
+// If a project was selected with UseProject, that project's roles are checked.
+// If Config.ScopeRolesToOrg is set, only grants in the token's org count.
 func HasRole(c *gin.Context, role string) bool {
 	cl := GetClaims(c)
-	if cl == nil || cl.Roles == nil {
+	if cl == nil {
+		return false
+	}
+	roles := rolesFor(c, cl)
+	if roles == nil {
+		return false
+	}
+	grants, ok := roles[role]
+	if !ok {
 		return false
 	}
-	_, ok := cl.Roles[role]
-	return ok
+
+	// With Config.ScopeRolesToOrg, the role must be granted in the active org.
+	// An org inferred from the grants themselves is not an active org: on
+	// multi-org tokens it would be arbitrary, so the check is denied.
+	if c.GetBool(orgScopedRolesKey) {
+		orgs, ok := grants.(map[string]interface{})
+		if !ok || !cl.HasOrg() {
+			return false
+		}
+		_, ok = orgs[cl.OrgID]
+		return ok
+	}
+	return true
+}
+
+// OrgsWithRole returns the sorted IDs of the organizations in which the user
+// holds the given role, read from the roles claim's { orgID: domain } grants.
+func OrgsWithRole(c *gin.Context, role string) []string {
+	cl := GetClaims(c)
+	if cl == nil {
+		return nil
+	}
+	grants, ok := rolesFor(c, cl)[role].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	orgs := make([]string, 0, len(grants))
+	for orgID := range grants {
+		orgs = append(orgs, orgID)
+	}
+	sort.Strings(orgs)
+	return orgs
+}
+
+// HasRoleInAnyOrg checks if the user holds the role in at least one organization.
+func HasRoleInAnyOrg(c *gin.Context, role string) bool {
+	return len(OrgsWithRole(c, role)) > 0
 }
 
 // HasAnyRole checks if the authenticated user has at least one of the specified roles.
@@ -98,3 +312,14 @@ func HasAnyRole(c *gin.Context, roles ...string) bool {
 	}
 	return false
 }
+
+// Claim returns the raw value of the named claim from the validated token.
+// The second return value reports whether the claim was present.
+func Claim(c *gin.Context, key string) (interface{}, bool) {
+	cl := GetClaims(c)
+	if cl == nil || cl.Raw == nil {
+		return nil, false
+	}
+	v, ok := cl.Raw[key]
+	return v, ok
+}
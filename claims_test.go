@@ -0,0 +1,36 @@
+package authkit
+
+import (
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestClaimsHasOrg(t *testing.T) {
+	roles := map[string]interface{}{"admin": map[string]interface{}{"org-a": "a.example.com"}}
+	tests := []struct {
+		name   string
+		claims jwt.MapClaims
+		want   bool
+	}{
+		{name: "org claim", claims: jwt.MapClaims{"urn:zitadel:iam:org:id": "org-a"}, want: true},
+		{name: "inferred from roles", claims: jwt.MapClaims{"urn:zitadel:iam:org:project:roles": roles}},
+		{name: "no org", claims: jwt.MapClaims{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cl, err := DecodeClaimsUnverified(signTestToken(t, tt.claims))
+			if err != nil {
+				t.Fatalf("DecodeClaimsUnverified: %v", err)
+			}
+			if got := cl.HasOrg(); got != tt.want {
+				t.Errorf("HasOrg() = %v, want %v (OrgID %q)", got, tt.want, cl.OrgID)
+			}
+			cl.SetOrgID(cl.OrgID)
+			if got := cl.HasOrg(); got != (cl.OrgID != "") {
+				t.Errorf("after SetOrgID(%q): HasOrg() = %v", cl.OrgID, got)
+			}
+		})
+	}
+}
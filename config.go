@@ -1,5 +1,14 @@
 package authkit
 
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
 // Config holds the configuration for the auth middleware.
 type Config struct {
 	// IssuerURL is the Zitadel issuer URL (e.g. "http://172.191.51.250:8080").
@@ -8,7 +17,210 @@ type Config struct {
 	// Audience is the expected audience claim (Zitadel project ID).
 	Audience string
 
+	// AdditionalAudiences lists further accepted audiences besides Audience,
+	// e.g. the client IDs Zitadel adds to "aud" alongside the project ID.
+	AdditionalAudiences []string
+
+	// AudienceMatchMode controls how the token's "aud" is compared to Audience
+	// and AdditionalAudiences. The default, AudienceMatchIntersect, accepts a
+	// token containing any of them; AudienceMatchExact requires the token's
+	// audiences to be exactly that set, rejecting tokens with extra audiences.
+	AudienceMatchMode AudienceMatchMode
+
+	// RequireAudience makes an empty Audience a configuration error instead of
+	// silently accepting tokens for any audience. Recommended for all new
+	// deployments; off by default for backward compatibility.
+	RequireAudience bool
+
+	// TokenMode selects which credentials AuthN accepts: OIDC JWT access tokens
+	// (TokenModeJWT, the default) or Zitadel v2 session tokens (TokenModeSession).
+	// Session tokens have no audience, so the audience settings must be unset
+	// in TokenModeSession.
+	TokenMode TokenMode
+
+	// ServiceToken is the service-user token used to call the Zitadel Session
+	// API in TokenModeSession. It must be allowed to read sessions.
+	ServiceToken string
+
+	// SessionIDHeader names the request header carrying the session ID in
+	// TokenModeSession. Defaults to DefaultSessionIDHeader.
+	SessionIDHeader string
+
+	// TokenHeaders lists the headers checked, in order, for a "Bearer <token>"
+	// value. Defaults to ["Authorization"]; add e.g. "X-Forwarded-Authorization"
+	// when running behind a gateway that renames the original header.
+	TokenHeaders []string
+
+	// DisableQueryToken turns off the "token" query-parameter fallback so only
+	// headers are accepted. Recommended wherever clients can send headers:
+	// tokens in URLs end up in access logs, proxy logs and browser history.
+	// Off by default for backward compatibility with WebSocket clients; keep
+	// such routes on a separate AuthN if you disable it elsewhere.
+	DisableQueryToken bool
+
+	// RequestIDHeader names the header carrying the request's correlation ID,
+	// which AuthN includes in its logs and exposes via RequestID. A random ID
+	// is generated when the header is absent, longer than 128 bytes, or holds
+	// anything but printable ASCII. Defaults to DefaultRequestIDHeader.
+	RequestIDHeader string
+
 	// SkipPaths lists route paths that bypass authentication (e.g. health checks).
-	// These should match Gin's FullPath() patterns (e.g. "/api/v1/health").
+	// Each entry may be either Gin's FullPath() pattern (e.g. "/api/:version/health")
+	// or a concrete request path (e.g. "/api/v1/health"). Wildcards are not supported.
 	SkipPaths []string
+
+	// TokenCacheTTL enables an in-memory cache of validated JWTs: a token seen
+	// again within this window (and before it expires) skips signature
+	// verification. Zero (the default) disables the cache.
+	TokenCacheTTL time.Duration
+
+	// RevocationChecker, if set, is consulted on every authenticated request
+	// (including token cache hits) with the token's jti; revoked tokens are
+	// rejected with 401 token_revoked. Tokens without a jti are not checked.
+	RevocationChecker RevocationChecker
+
+	// Keys, if set, resolves signing keys instead of fetching the JWKS from
+	// IssuerURL, e.g. a fixed key set from NewStaticJWKSCache or a custom
+	// KeyProvider for offline tests or pinned keys. The JWKS-fetch settings
+	// below are then ignored.
+	Keys KeyProvider
+
+	// SkipIssuerCheck disables the "iss" check, so IssuerURL may be empty.
+	// Only allowed together with Keys: keys fetched from a JWKS endpoint are
+	// typically shared by every tenant of the issuer, so their tokens must
+	// always be bound to it.
+	SkipIssuerCheck bool
+
+	// KeyStore optionally persists JWKS keys across restarts so cold starts can
+	// skip the initial fetch. When nil, keys are cached in memory only.
+	KeyStore KeyStore
+
+	// JWKSHeaders are extra headers sent with every JWKS fetch, for endpoints
+	// behind an auth proxy that expects a static API key. Empty by default.
+	JWKSHeaders map[string]string
+
+	// JWKSURL overrides where signing keys are fetched from, for issuers that
+	// don't serve them at Zitadel's path. Used verbatim when set; by default it
+	// is derived from IssuerURL with JWKSURLFromIssuer.
+	JWKSURL string
+
+	// JWKSTimeout bounds each JWKS fetch HTTP call. Defaults to 10s when zero.
+	JWKSTimeout time.Duration
+
+	// JWKSKeyGracePeriod keeps signing keys usable for this long after they
+	// disappear from the JWKS, smoothing over key rotation. Zero disables it.
+	JWKSKeyGracePeriod time.Duration
+
+	// WarmJWKS makes NewAuthN fetch the JWKS at construction so the first
+	// request doesn't pay for it. A failed warm-up is logged and keys are
+	// fetched lazily as usual; use FailClosedOnJWKS to make it fatal instead.
+	WarmJWKS bool
+
+	// FailClosedOnJWKS makes NewAuthN fetch the JWKS at construction and return
+	// an error (AuthN panics) if it is unreachable, so the app refuses to start.
+	// By default keys are fetched lazily on the first request.
+	FailClosedOnJWKS bool
+
+	// MinRSAKeyBits is the smallest RSA signing key accepted from the JWKS.
+	// Defaults to DefaultMinRSAKeyBits (2048) when zero.
+	MinRSAKeyBits int
+
+	// MergeProjectRoles merges the roles from every project-scoped roles claim
+	// ("urn:zitadel:iam:org:project:{projectId}:roles") into Claims.Roles, for
+	// gateways fronting several projects under one token.
+	MergeProjectRoles bool
+
+	// ScopeRolesToOrg makes role checks count only grants made in the token's
+	// active org (OrgID), so a role held in org B doesn't satisfy a check while
+	// acting in org A. Recommended for tokens carrying multi-org grants. The
+	// token must carry the "urn:zitadel:iam:org:id" claim, or EnrichClaims
+	// must change OrgID or confirm it with Claims.SetOrgID; an org only
+	// inferred from role grants satisfies no check.
+	ScopeRolesToOrg bool
+
+	// EnrichClaims, if set, is called by AuthN with each request's validated
+	// claims just before they are stored, to add app-specific data (e.g. map
+	// OrgID to an internal tenant ID) that later middleware should see. With
+	// TokenCacheTTL the claims are a per-request copy, but their maps are shared
+	// with the cache and must not be modified in place.
+	EnrichClaims func(c *gin.Context, cl *Claims)
+
+	// ReportOnly makes AuthN log invalid or missing tokens and record the error
+	// (see AuthError) but still call the next handler, to measure how many
+	// clients would be rejected before enforcing. NEVER enable this where
+	// authentication is meant to be enforced.
+	ReportOnly bool
+
+	// DebugAuthz includes the user's actual role names in RequireRole's 403
+	// responses. DEVELOPMENT ONLY: this leaks authorization details to clients
+	// and must stay disabled (the default) in production.
+	DebugAuthz bool
+}
+
+// AudienceMatchMode selects how a token's audiences are matched against the
+// configured ones.
+type AudienceMatchMode int
+
+const (
+	// AudienceMatchIntersect accepts a token whose "aud" contains at least one
+	// configured audience. This is the default.
+	AudienceMatchIntersect AudienceMatchMode = iota
+
+	// AudienceMatchExact accepts only a token whose "aud" set equals the
+	// configured audiences, with no additional values.
+	AudienceMatchExact
+)
+
+// audiences returns Audience followed by AdditionalAudiences, skipping empty
+// entries.
+func (cfg Config) audiences() []string {
+	var out []string
+	if cfg.Audience != "" {
+		out = append(out, cfg.Audience)
+	}
+	for _, aud := range cfg.AdditionalAudiences {
+		if aud != "" {
+			out = append(out, aud)
+		}
+	}
+	return out
+}
+
+// Validate reports configuration mistakes that would otherwise only surface as
+// runtime 401s or, worse, as silently accepted tokens.
+func (cfg Config) Validate() error {
+	// IssuerURL locates the JWKS and the Session API, and is always compared
+	// against "iss"; only fixed Keys may opt out of that with SkipIssuerCheck.
+	if cfg.SkipIssuerCheck && (cfg.Keys == nil || cfg.TokenMode == TokenModeSession) {
+		return errors.New("authkit: SkipIssuerCheck requires Keys and TokenModeJWT")
+	}
+	if !cfg.SkipIssuerCheck {
+		if cfg.IssuerURL == "" {
+			return errors.New("authkit: IssuerURL is required")
+		}
+		if u, err := url.Parse(cfg.IssuerURL); err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("authkit: IssuerURL %q is not an absolute URL", cfg.IssuerURL)
+		}
+	}
+	if cfg.JWKSURL != "" {
+		if u, err := url.Parse(cfg.JWKSURL); err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("authkit: JWKSURL %q is not an absolute URL", cfg.JWKSURL)
+		}
+	}
+	if cfg.RequireAudience && cfg.Audience == "" {
+		return errors.New("authkit: RequireAudience is set but no Audience is configured")
+	}
+	if cfg.AudienceMatchMode == AudienceMatchExact && len(cfg.audiences()) == 0 {
+		return errors.New("authkit: AudienceMatchExact is set but no Audience is configured")
+	}
+	if cfg.TokenMode == TokenModeSession {
+		if cfg.ServiceToken == "" {
+			return errors.New("authkit: TokenModeSession requires a ServiceToken")
+		}
+		// Session tokens have no audience, so these would silently never apply.
+		if len(cfg.audiences()) > 0 || cfg.AudienceMatchMode == AudienceMatchExact || cfg.RequireAudience {
+			return errors.New("authkit: Audience, AdditionalAudiences, AudienceMatchExact and RequireAudience are not supported with TokenModeSession")
+		}
+	}
+	return nil
 }
@@ -0,0 +1,22 @@
+package authkit
+
+import (
+	"context"
+)
+
+// claimsContextKey is the context.Context key for Claims. It is unexported so
+// only this package can set or read the value.
+type claimsContextKey struct{}
+
+// NewContextWithClaims returns a copy of ctx carrying the given claims. Use it
+// outside of Gin (e.g. message-queue consumers) after ValidateToken.
+func NewContextWithClaims(ctx context.Context, cl *Claims) context.Context {
+	return context.WithValue(ctx, claimsContextKey{}, cl)
+}
+
+// ClaimsFromContext retrieves claims stored by NewContextWithClaims.
+// Returns nil if ctx carries no claims.
+func ClaimsFromContext(ctx context.Context) *Claims {
+	cl, _ := ctx.Value(claimsContextKey{}).(*Claims)
+	return cl
+}
@@ -0,0 +1,24 @@
+package authkit
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// responseBody returns resp's body, decompressing it if the server sent it
+// gzip-encoded. Go's default transport does this itself and strips the
+// header, but custom transports (or DisableCompression) may not. The caller
+// still closes resp.Body.
+func responseBody(resp *http.Response) (io.Reader, error) {
+	if !strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		return resp.Body, nil
+	}
+	zr, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid gzip response: %w", err)
+	}
+	return zr, nil
+}
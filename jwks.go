@@ -1,12 +1,20 @@
 package authkit
 
 import (
+	"context"
+	"crypto"
 	"crypto/rsa"
+	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
+	"encoding/pem"
+	"errors"
 	"fmt"
+	"io"
+	"log"
 	"math/big"
 	"net/http"
+	"sort"
 	"sync"
 	"time"
 )
@@ -27,6 +35,10 @@ type jwksResponse struct {
 }
 
 // JWKSCache fetches and caches JWKS keys from the identity provider.
+//
+// Keys are refreshed lazily on the request path; the cache starts no
+// background goroutines or timers. Call Close during shutdown to release the
+// HTTP connections it holds open.
 type JWKSCache struct {
 	jwksURL    string
 	keys       map[string]*rsa.PublicKey
@@ -34,55 +46,261 @@ type JWKSCache struct {
 	lastFetch  time.Time
 	cacheTTL   time.Duration
 	httpClient *http.Client
+	store      KeyStore
+	headers    map[string]string
+	minRSABits int
+
+	// gracePeriod keeps keys that disappear from the JWKS usable for a while
+	// after the refresh that dropped them; retired holds them until then.
+	gracePeriod time.Duration
+	retired     map[string]retiredKey
+
+	// static caches hold a fixed key set and never fetch.
+	static bool
+}
+
+// retiredKey is a key no longer published in the JWKS, still accepted until
+// its grace period ends.
+type retiredKey struct {
+	key   *rsa.PublicKey
+	until time.Time
+}
+
+// JWKSOption configures optional JWKSCache behavior.
+type JWKSOption func(*JWKSCache)
+
+// WithKeyStore persists fetched keys to store and seeds the cache from it on
+// construction, skipping the initial fetch while the stored keys are fresh.
+func WithKeyStore(store KeyStore) JWKSOption {
+	return func(j *JWKSCache) {
+		j.store = store
+	}
+}
+
+// WithHeaders adds extra headers (e.g. an API key required by an auth proxy in
+// front of the JWKS endpoint) to every JWKS fetch.
+func WithHeaders(headers map[string]string) JWKSOption {
+	return func(j *JWKSCache) {
+		j.headers = headers
+	}
+}
+
+// WithFetchTimeout sets the HTTP timeout for JWKS fetches (default 10s). It
+// applies only to the key-fetch call, independently of any request deadline.
+func WithFetchTimeout(d time.Duration) JWKSOption {
+	return func(j *JWKSCache) {
+		j.httpClient.Timeout = d
+	}
+}
+
+// DefaultMinRSAKeyBits is the smallest RSA modulus accepted from the JWKS
+// unless overridden with WithMinRSAKeyBits.
+const DefaultMinRSAKeyBits = 2048
+
+// WithMinRSAKeyBits sets the minimum RSA key size accepted from the JWKS.
+// Smaller keys are skipped with a logged warning.
+func WithMinRSAKeyBits(bits int) JWKSOption {
+	return func(j *JWKSCache) {
+		j.minRSABits = bits
+	}
+}
+
+// WithKeyGracePeriod keeps keys that a refresh no longer finds in the JWKS for
+// d afterwards, so tokens signed just before a key rotation keep validating
+// while both keys are in use. Zero (the default) drops removed keys at once.
+func WithKeyGracePeriod(d time.Duration) JWKSOption {
+	return func(j *JWKSCache) {
+		j.gracePeriod = d
+	}
 }
 
 // NewJWKSCache creates a new JWKS cache for the given URL.
-func NewJWKSCache(jwksURL string) *JWKSCache {
-	return &JWKSCache{
-		jwksURL:  jwksURL,
-		keys:     make(map[string]*rsa.PublicKey),
-		cacheTTL: 1 * time.Hour,
+func NewJWKSCache(jwksURL string, opts ...JWKSOption) *JWKSCache {
+	j := &JWKSCache{
+		jwksURL:    jwksURL,
+		keys:       make(map[string]*rsa.PublicKey),
+		retired:    make(map[string]retiredKey),
+		cacheTTL:   1 * time.Hour,
+		minRSABits: DefaultMinRSAKeyBits,
 		httpClient: &http.Client{
 			Timeout: 10 * time.Second,
 		},
 	}
+	for _, opt := range opts {
+		opt(j)
+	}
+	if j.store != nil {
+		j.loadFromStore()
+	}
+	return j
+}
+
+// NewStaticJWKSCache creates a JWKSCache from a fixed JWKS document (e.g. one
+// embedded in the binary or read from disk) that never fetches or refreshes,
+// for air-gapped tests and deployments that pin their signing keys. Of the
+// options only WithMinRSAKeyBits applies.
+func NewStaticJWKSCache(jwksJSON []byte, opts ...JWKSOption) (*JWKSCache, error) {
+	j := newStaticJWKSCache(opts)
+	keys, err := parseJWKS(jwksJSON, j.minRSABits)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("static JWKS contains no usable signing keys")
+	}
+	j.keys = keys
+	return j, nil
+}
+
+// NewStaticJWKSCacheFromPEM is like NewStaticJWKSCache but takes PEM-encoded
+// RSA public keys ("PUBLIC KEY" or "RSA PUBLIC KEY" blocks) by key ID.
+func NewStaticJWKSCacheFromPEM(pemKeys map[string][]byte, opts ...JWKSOption) (*JWKSCache, error) {
+	j := newStaticJWKSCache(opts)
+	for kid, data := range pemKeys {
+		key, err := parseRSAPublicKeyPEM(data)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", kid, err)
+		}
+		if bits := key.N.BitLen(); bits < j.minRSABits {
+			return nil, fmt.Errorf("key %q: %d-bit RSA key is below the %d-bit minimum", kid, bits, j.minRSABits)
+		}
+		j.keys[kid] = key
+	}
+	if len(j.keys) == 0 {
+		return nil, errors.New("no PEM keys provided")
+	}
+	return j, nil
+}
+
+func newStaticJWKSCache(opts []JWKSOption) *JWKSCache {
+	j := &JWKSCache{
+		keys:       make(map[string]*rsa.PublicKey),
+		retired:    make(map[string]retiredKey),
+		cacheTTL:   1 * time.Hour,
+		minRSABits: DefaultMinRSAKeyBits,
+		httpClient: &http.Client{},
+		static:     true,
+	}
+	for _, opt := range opts {
+		opt(j)
+	}
+	j.lastFetch = time.Now()
+	return j
+}
+
+// GetKey returns the RSA public key for the given key ID, implementing
+// KeyProvider. It fetches fresh keys if the cache is stale or the key ID is
+// unknown.
+func (j *JWKSCache) GetKey(kid string) (crypto.PublicKey, error) {
+	return j.GetKeyContext(context.Background(), kid)
 }
 
-// GetKey returns the RSA public key for the given key ID.
-// It fetches fresh keys if the cache is stale or the key ID is unknown.
-func (j *JWKSCache) GetKey(kid string) (*rsa.PublicKey, error) {
+// GetKeyContext is like GetKey but uses ctx for any JWKS fetch it triggers.
+func (j *JWKSCache) GetKeyContext(ctx context.Context, kid string) (crypto.PublicKey, error) {
 	// Try cached key first
 	j.mu.RLock()
-	if key, ok := j.keys[kid]; ok && time.Since(j.lastFetch) < j.cacheTTL {
+	if key, ok := j.keys[kid]; ok && (j.static || time.Since(j.lastFetch) < j.cacheTTL) {
 		j.mu.RUnlock()
 		return key, nil
 	}
+	if r, ok := j.retired[kid]; ok && time.Now().Before(r.until) {
+		j.mu.RUnlock()
+		return r.key, nil
+	}
 	j.mu.RUnlock()
 
 	// Fetch fresh keys
-	if err := j.refresh(); err != nil {
+	if err := j.refresh(ctx); err != nil {
 		return nil, fmt.Errorf("failed to refresh JWKS: %w", err)
 	}
 
 	j.mu.RLock()
 	defer j.mu.RUnlock()
-	key, ok := j.keys[kid]
-	if !ok {
-		return nil, fmt.Errorf("key %q not found in JWKS", kid)
+	if key, ok := j.keys[kid]; ok {
+		return key, nil
 	}
-	return key, nil
+	if r, ok := j.retired[kid]; ok && time.Now().Before(r.until) {
+		return r.key, nil
+	}
+	return nil, fmt.Errorf("key %q not found in JWKS", kid)
 }
 
-func (j *JWKSCache) refresh() error {
+// Close releases idle HTTP connections held by the cache. It is safe to call
+// more than once; a closed cache still serves keys and will reconnect if it
+// needs to refresh.
+func (j *JWKSCache) Close() error {
+	j.httpClient.CloseIdleConnections()
+	return nil
+}
+
+// warm fetches keys eagerly, failing if the JWKS cannot be fetched or holds no
+// usable signing keys.
+func (j *JWKSCache) warm(ctx context.Context) error {
+	if err := j.refresh(ctx); err != nil {
+		return err
+	}
+
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	if len(j.keys) == 0 {
+		return fmt.Errorf("JWKS at %s contains no usable signing keys", j.jwksURL)
+	}
+	return nil
+}
+
+// JWKSStats describes the current state of a JWKSCache for diagnostics.
+type JWKSStats struct {
+	// LastRefresh is when keys were last fetched (zero if never).
+	LastRefresh time.Time
+
+	// KeyCount is the number of signing keys currently cached.
+	KeyCount int
+
+	// KeyIDs lists the cached key IDs in sorted order.
+	KeyIDs []string
+}
+
+// Stats returns a snapshot of the cache's refresh time and cached keys.
+// Useful for a /debug/jwks endpoint or alerting on stale caches.
+func (j *JWKSCache) Stats() JWKSStats {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	ids := make([]string, 0, len(j.keys))
+	for kid := range j.keys {
+		ids = append(ids, kid)
+	}
+	sort.Strings(ids)
+
+	return JWKSStats{
+		LastRefresh: j.lastFetch,
+		KeyCount:    len(j.keys),
+		KeyIDs:      ids,
+	}
+}
+
+func (j *JWKSCache) refresh(ctx context.Context) error {
 	j.mu.Lock()
 	defer j.mu.Unlock()
 
+	if j.static {
+		return nil
+	}
+
 	// Double-check after acquiring write lock
 	if time.Since(j.lastFetch) < 30*time.Second {
 		return nil
 	}
 
-	resp, err := j.httpClient.Get(j.jwksURL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.jwksURL, http.NoBody)
+	if err != nil {
+		return fmt.Errorf("failed to build JWKS request: %w", err)
+	}
+	for k, v := range j.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := j.httpClient.Do(req)
 	if err != nil {
 		return fmt.Errorf("JWKS fetch failed: %w", err)
 	}
@@ -92,12 +310,83 @@ func (j *JWKSCache) refresh() error {
 		return fmt.Errorf("JWKS endpoint returned status %d", resp.StatusCode)
 	}
 
+	r, err := responseBody(resp)
+	if err != nil {
+		return fmt.Errorf("failed to read JWKS: %w", err)
+	}
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("failed to read JWKS: %w", err)
+	}
+
+	newKeys, err := parseJWKS(body, j.minRSABits)
+	if err != nil {
+		return err
+	}
+
+	j.retireKeys(newKeys)
+	j.keys = newKeys
+	j.lastFetch = time.Now()
+
+	if j.store != nil {
+		if err := j.store.Save(body, j.lastFetch); err != nil {
+			log.Printf("[authkit] Failed to persist JWKS to key store: %v", err)
+		}
+	}
+	return nil
+}
+
+// retireKeys moves keys that are missing from next into the retired set for
+// the grace period, and forgets retired keys whose grace period has ended or
+// that are published again. Callers must hold j.mu.
+func (j *JWKSCache) retireKeys(next map[string]*rsa.PublicKey) {
+	now := time.Now()
+	for kid, r := range j.retired {
+		if _, ok := next[kid]; ok || !now.Before(r.until) {
+			delete(j.retired, kid)
+		}
+	}
+	if j.gracePeriod <= 0 {
+		return
+	}
+	for kid, key := range j.keys {
+		if _, ok := next[kid]; !ok {
+			j.retired[kid] = retiredKey{key: key, until: now.Add(j.gracePeriod)}
+		}
+	}
+}
+
+// loadFromStore seeds the cache from the configured KeyStore if the stored
+// document is still within the cache TTL. Failures fall back to a live fetch.
+func (j *JWKSCache) loadFromStore() {
+	body, fetchedAt, err := j.store.Load()
+	if err != nil {
+		log.Printf("[authkit] Failed to load JWKS from key store: %v", err)
+		return
+	}
+	if len(body) == 0 || time.Since(fetchedAt) >= j.cacheTTL {
+		return
+	}
+
+	keys, err := parseJWKS(body, j.minRSABits)
+	if err != nil {
+		log.Printf("[authkit] Ignoring stored JWKS: %v", err)
+		return
+	}
+
+	j.keys = keys
+	j.lastFetch = fetchedAt
+}
+
+// parseJWKS decodes a JWKS document and returns its RSA signing keys by key ID.
+// Keys with a modulus shorter than minBits are skipped.
+func parseJWKS(body []byte, minBits int) (map[string]*rsa.PublicKey, error) {
 	var jwks jwksResponse
-	if err := json.NewDecoder(resp.Body).Decode(&jwks); err != nil {
-		return fmt.Errorf("failed to decode JWKS: %w", err)
+	if err := json.Unmarshal(body, &jwks); err != nil {
+		return nil, fmt.Errorf("failed to decode JWKS: %w", err)
 	}
 
-	newKeys := make(map[string]*rsa.PublicKey, len(jwks.Keys))
+	keys := make(map[string]*rsa.PublicKey, len(jwks.Keys))
 	for _, k := range jwks.Keys {
 		if k.Kty != "RSA" || k.Use != "sig" {
 			continue
@@ -106,12 +395,41 @@ func (j *JWKSCache) refresh() error {
 		if err != nil {
 			continue
 		}
-		newKeys[k.Kid] = pubKey
+		if bits := pubKey.N.BitLen(); bits < minBits {
+			log.Printf("[authkit] Skipping JWKS key %q: %d-bit RSA key is below the %d-bit minimum", k.Kid, bits, minBits)
+			continue
+		}
+		keys[k.Kid] = pubKey
 	}
+	return keys, nil
+}
 
-	j.keys = newKeys
-	j.lastFetch = time.Now()
-	return nil
+// parseRSAPublicKeyPEM decodes a PEM-encoded PKIX or PKCS#1 RSA public key.
+func parseRSAPublicKeyPEM(data []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	switch block.Type {
+	case "PUBLIC KEY":
+		pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid public key: %w", err)
+		}
+		key, ok := pub.(*rsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("unsupported public key type %T", pub)
+		}
+		return key, nil
+	case "RSA PUBLIC KEY":
+		key, err := x509.ParsePKCS1PublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RSA public key: %w", err)
+		}
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type %q", block.Type)
+	}
 }
 
 func parseRSAPublicKey(nStr, eStr string) (*rsa.PublicKey, error) {
@@ -0,0 +1,36 @@
+package authkit
+
+import (
+	"context"
+	"crypto"
+)
+
+// KeyProvider resolves a token's signing key by key ID. *JWKSCache is the
+// standard implementation; tests and offline deployments can set their own on
+// Config.Keys. Keys must be *rsa.PublicKey, as only RS256 tokens are accepted.
+type KeyProvider interface {
+	GetKey(kid string) (crypto.PublicKey, error)
+}
+
+// contextKeyProvider is implemented by providers (like *JWKSCache) whose key
+// lookup may do I/O that should honor the request context.
+type contextKeyProvider interface {
+	GetKeyContext(ctx context.Context, kid string) (crypto.PublicKey, error)
+}
+
+// lookupKey resolves kid with p, passing ctx along when p supports it.
+func lookupKey(ctx context.Context, p KeyProvider, kid string) (crypto.PublicKey, error) {
+	if cp, ok := p.(contextKeyProvider); ok {
+		return cp.GetKeyContext(ctx, kid)
+	}
+	return p.GetKey(kid)
+}
+
+// keyProviderFromConfig returns cfg.Keys, or a JWKS cache built from cfg when
+// no provider was supplied.
+func keyProviderFromConfig(cfg Config) KeyProvider {
+	if cfg.Keys != nil {
+		return cfg.Keys
+	}
+	return newJWKSCacheFromConfig(cfg)
+}
@@ -0,0 +1,68 @@
+package authkit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// KeyStore persists the raw JWKS document so a JWKSCache can be seeded after a
+// restart instead of fetching on the first request. Implementations may back
+// onto disk, Redis, or any other shared cache.
+type KeyStore interface {
+	// Load returns the last saved JWKS document and the time it was fetched.
+	// It returns an empty document and a nil error when nothing is stored.
+	Load() (jwks []byte, fetchedAt time.Time, err error)
+
+	// Save persists a freshly fetched JWKS document.
+	Save(jwks []byte, fetchedAt time.Time) error
+}
+
+// FileKeyStore is a KeyStore that keeps the JWKS document in a local file.
+type FileKeyStore struct {
+	Path string
+}
+
+// fileKeyStoreRecord is the on-disk format used by FileKeyStore.
+type fileKeyStoreRecord struct {
+	FetchedAt time.Time       `json:"fetched_at"`
+	JWKS      json.RawMessage `json:"jwks"`
+}
+
+// NewFileKeyStore creates a KeyStore that persists keys to the given path.
+func NewFileKeyStore(path string) *FileKeyStore {
+	return &FileKeyStore{Path: path}
+}
+
+// Load reads the stored JWKS document. A missing file is not an error.
+func (f *FileKeyStore) Load() ([]byte, time.Time, error) {
+	data, err := os.ReadFile(f.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, time.Time{}, nil
+	}
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to read key store: %w", err)
+	}
+
+	var rec fileKeyStoreRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to decode key store: %w", err)
+	}
+	return rec.JWKS, rec.FetchedAt, nil
+}
+
+// Save writes the JWKS document to disk, replacing any previous contents.
+func (f *FileKeyStore) Save(jwks []byte, fetchedAt time.Time) error {
+	data, err := json.Marshal(fileKeyStoreRecord{FetchedAt: fetchedAt, JWKS: jwks})
+	if err != nil {
+		return fmt.Errorf("failed to encode key store: %w", err)
+	}
+
+	tmp := f.Path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write key store: %w", err)
+	}
+	return os.Rename(tmp, f.Path)
+}
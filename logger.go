@@ -0,0 +1,43 @@
+package authkit
+
+import (
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+)
+
+const loggerKey = "dromos_auth_logger"
+
+// ContextLogger returns a Gin middleware that stores a request-scoped logger
+// enriched with the authenticated user's user_id and org_id, and AuthN's
+// request_id, in the Gin context. This must be applied AFTER AuthN. If base is
+// nil, slog.Default() is used.
+func ContextLogger(base *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := base
+		if logger == nil {
+			logger = slog.Default()
+		}
+
+		c.Set(loggerKey, logger.With(
+			slog.String("user_id", UserID(c)),
+			slog.String("org_id", OrgID(c)),
+			slog.String("request_id", RequestID(c)),
+		))
+		c.Next()
+	}
+}
+
+// LoggerFromContext retrieves the request-scoped logger from the Gin context.
+// Returns slog.Default() if ContextLogger has not run for this request.
+func LoggerFromContext(c *gin.Context) *slog.Logger {
+	val, exists := c.Get(loggerKey)
+	if !exists {
+		return slog.Default()
+	}
+	logger, ok := val.(*slog.Logger)
+	if !ok {
+		return slog.Default()
+	}
+	return logger
+}
@@ -0,0 +1,56 @@
+package authkit
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultMFAMethods are the "amr" values RequireMFA accepts when none are given.
+var DefaultMFAMethods = []string{"mfa", "otp"}
+
+// RequireMFA returns a Gin middleware that rejects requests whose token was not
+// obtained with a second factor, according to the "amr" claim. If no methods
+// are given, DefaultMFAMethods is used. Must be applied AFTER AuthN.
+func RequireMFA(methods ...string) gin.HandlerFunc {
+	if len(methods) == 0 {
+		methods = DefaultMFAMethods
+	}
+	accepted := make(map[string]bool, len(methods))
+	for _, m := range methods {
+		accepted[m] = true
+	}
+
+	return func(c *gin.Context) {
+		if cl := GetClaims(c); cl != nil {
+			for _, m := range cl.AMR {
+				if accepted[m] {
+					c.Next()
+					return
+				}
+			}
+		}
+
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"error": "multi-factor authentication required",
+		})
+	}
+}
+
+// RequireMaxTokenAge returns a Gin middleware that requires the token to have
+// been issued within the last d, regardless of its exp (step-up freshness for
+// sensitive actions). Tokens without an iat claim are rejected. Must be
+// applied AFTER AuthN.
+func RequireMaxTokenAge(d time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if cl := GetClaims(c); cl != nil && !cl.IssuedAt.IsZero() && time.Since(cl.IssuedAt) <= d {
+			c.Next()
+			return
+		}
+
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"error": "re-authentication required — token is too old for this action",
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package authkit
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// ValidatingHandler returns an http.Handler that validates the request's bearer
+// token before calling next, for plain net/http servers such as a reverse
+// proxy built on httputil.ReverseProxy. It shares AuthN's validation rules and
+// keeps its own JWKS cache for the lifetime of the handler. The validated
+// claims are available to next via ClaimsFromContext(r.Context()).
+//
+// Invalid requests are rejected with the same 401 JSON bodies as AuthN.
+// SkipPaths entries are matched against the request path only, as there are
+// no route patterns. These Config fields are Gin-specific or tied to AuthN's
+// lifecycle and are ignored here: ReportOnly, TokenCacheTTL, EnrichClaims
+// (it takes a *gin.Context), DebugAuthz, ScopeRolesToOrg, RequestIDHeader,
+// WarmJWKS and FailClosedOnJWKS.
+// It panics if cfg.Validate reports an error.
+func ValidatingHandler(cfg Config, next http.Handler) http.Handler {
+	if err := cfg.Validate(); err != nil {
+		panic(err)
+	}
+
+	var keys KeyProvider
+	var sessions *sessionValidator
+	if cfg.TokenMode == TokenModeSession {
+		sessions = newSessionValidator(cfg)
+	} else {
+		keys = keyProviderFromConfig(cfg)
+	}
+
+	sessionIDHeader := cfg.SessionIDHeader
+	if sessionIDHeader == "" {
+		sessionIDHeader = DefaultSessionIDHeader
+	}
+	tokenOpts := tokenOptionsFromConfig(cfg)
+
+	skipSet := make(map[string]bool, len(cfg.SkipPaths))
+	for _, p := range cfg.SkipPaths {
+		skipSet[p] = true
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if skipSet[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		tokenStr := TokenFromRequest(r, tokenOpts...)
+		if tokenStr == "" {
+			writeUnauthorized(w, ErrCodeTokenMissing, "missing or invalid Authorization header")
+			return
+		}
+
+		var claims *Claims
+		var err error
+		if sessions != nil {
+			claims, err = sessions.validate(r.Context(), r.Header.Get(sessionIDHeader), tokenStr)
+		} else {
+			claims, err = authenticateJWT(r.Context(), tokenStr, cfg, keys)
+		}
+		if err == nil {
+			err = checkRevoked(r.Context(), cfg, claims)
+		}
+		if err != nil {
+			code, msg := failureResponse(err)
+			writeUnauthorized(w, code, msg)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(NewContextWithClaims(r.Context(), claims)))
+	})
+}
+
+// writeUnauthorized writes AuthN's 401 JSON body to a plain ResponseWriter.
+func writeUnauthorized(w http.ResponseWriter, code, msg string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusUnauthorized)
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"error":      msg,
+		"error_code": code,
+	})
+}
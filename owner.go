@@ -0,0 +1,38 @@
+package authkit
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrNotOwner is returned by AssertOwner when the authenticated user is not
+// the owner of the resource.
+var ErrNotOwner = errors.New("authenticated user is not the resource owner")
+
+// AssertOwner checks that the authenticated user is the resource owner
+// (e.g. created_by == UserID(c)). Returns ErrNotOwner otherwise, including
+// when the request is unauthenticated or ownerUserID is empty.
+func AssertOwner(c *gin.Context, ownerUserID string) error {
+	userID := UserID(c)
+	if userID == "" || ownerUserID == "" || userID != ownerUserID {
+		return ErrNotOwner
+	}
+	return nil
+}
+
+// RequireOwner returns a Gin middleware that enforces object-level ownership.
+// extract resolves the owner's user ID for the request (from a path param,
+// a database lookup, etc.). Must be applied AFTER AuthN.
+func RequireOwner(extract func(*gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := AssertOwner(c, extract(c)); err != nil {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "insufficient permissions — only the resource owner may perform this action",
+			})
+			return
+		}
+		c.Next()
+	}
+}
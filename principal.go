@@ -0,0 +1,60 @@
+package authkit
+
+import (
+	"sort"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Principal is a snapshot of the authenticated user, built from the request's
+// Claims for handlers that want one value instead of several accessor calls.
+type Principal struct {
+	UserID    string
+	Email     string
+	OrgID     string
+	OrgDomain string
+
+	// Roles lists the sorted role names that HasRole would accept for this
+	// request, honoring UseProject and Config.ScopeRolesToOrg.
+	Roles []string
+}
+
+// GetPrincipal returns the authenticated user as a Principal.
+// Returns nil if no claims are set (unauthenticated request).
+func GetPrincipal(c *gin.Context) *Principal {
+	cl := GetClaims(c)
+	if cl == nil {
+		return nil
+	}
+
+	return &Principal{
+		UserID:    cl.Sub,
+		Email:     cl.Email,
+		OrgID:     cl.OrgID,
+		OrgDomain: cl.OrgDomain,
+		Roles:     userRoleNames(c),
+	}
+}
+
+// HasRole reports whether the principal holds the given role. A nil
+// Principal (unauthenticated request) holds no roles.
+func (p *Principal) HasRole(role string) bool {
+	if p == nil {
+		return false
+	}
+	i := sort.SearchStrings(p.Roles, role)
+	return i < len(p.Roles) && p.Roles[i] == role
+}
+
+// HasAnyRole reports whether the principal holds at least one of the roles.
+func (p *Principal) HasAnyRole(roles ...string) bool {
+	if p == nil {
+		return false
+	}
+	for _, role := range roles {
+		if p.HasRole(role) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package authkit
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+const projectKey = "dromos_auth_project"
+
+// UseProject returns a Gin middleware that scopes subsequent role checks
+// (HasRole, HasAnyRole, RequireRole) to the given Zitadel project, using the
+// token's "urn:zitadel:iam:org:project:{projectId}:roles" claim instead of the
+// generic roles claim. Apply it to a route group, AFTER AuthN.
+func UseProject(projectID string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(projectKey, projectID)
+		c.Next()
+	}
+}
+
+// ProjectID returns the project selected for role checks by UseProject.
+// Returns empty string if no project has been selected.
+func ProjectID(c *gin.Context) string {
+	return c.GetString(projectKey)
+}
+
+// RequireProjectRole is shorthand for UseProject(projectID) followed by
+// RequireRole(roles...), for a single route.
+func RequireProjectRole(projectID string, roles ...string) gin.HandlerFunc {
+	requireRole := RequireRole(roles...)
+	return func(c *gin.Context) {
+		c.Set(projectKey, projectID)
+		requireRole(c)
+	}
+}
+
+// rolesFor returns the role map that applies to the request: the selected
+// project's roles when UseProject is in effect, otherwise the generic claim.
+func rolesFor(c *gin.Context, cl *Claims) map[string]interface{} {
+	if projectID := ProjectID(c); projectID != "" {
+		return cl.ProjectRoles[projectID]
+	}
+	return cl.Roles
+}
+
+// mergeProjectRoles folds every project-scoped roles claim into Claims.Roles.
+// A role granted in several projects keeps the union of its org grants, so
+// the orgID → domain scoping is preserved. The token's own maps are copied,
+// not modified.
+func mergeProjectRoles(cl *Claims) {
+	if len(cl.ProjectRoles) == 0 {
+		return
+	}
+
+	merged := make(map[string]interface{})
+	add := func(roles map[string]interface{}) {
+		for role, grants := range roles {
+			grantsMap, ok := grants.(map[string]interface{})
+			if !ok {
+				if _, exists := merged[role]; !exists {
+					merged[role] = grants
+				}
+				continue
+			}
+
+			orgs, ok := merged[role].(map[string]interface{})
+			if !ok {
+				orgs = make(map[string]interface{}, len(grantsMap))
+				merged[role] = orgs
+			}
+			for orgID, domain := range grantsMap {
+				orgs[orgID] = domain
+			}
+		}
+	}
+
+	add(cl.Roles)
+	for _, roles := range cl.ProjectRoles {
+		add(roles)
+	}
+	cl.Roles = merged
+
+	if cl.OrgID == "" {
+		cl.OrgID = extractOrgIDFromRoles(cl.Roles)
+		cl.orgInferred = cl.OrgID != ""
+	}
+}
@@ -0,0 +1,52 @@
+package authkit
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Headers used to propagate the caller's identity to downstream services.
+const (
+	HeaderOrgID  = "X-Zitadel-Orgid"
+	HeaderUserID = "X-User-Id"
+)
+
+// OutgoingHeaders returns the headers that carry the authenticated user's org
+// and user ID to internal services. Headers are omitted when the value is empty.
+func OutgoingHeaders(c *gin.Context) http.Header {
+	h := make(http.Header)
+	if orgID := OrgID(c); orgID != "" {
+		h.Set(HeaderOrgID, orgID)
+	}
+	if userID := UserID(c); userID != "" {
+		h.Set(HeaderUserID, userID)
+	}
+	return h
+}
+
+// propagatingTransport adds a fixed set of headers to every outbound request.
+type propagatingTransport struct {
+	headers http.Header
+	base    http.RoundTripper
+}
+
+// PropagatingTransport returns an http.RoundTripper that adds OutgoingHeaders(c)
+// to every request it sends. If base is nil, http.DefaultTransport is used.
+// Build one per incoming request, since it captures that request's identity.
+func PropagatingTransport(c *gin.Context, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &propagatingTransport{headers: OutgoingHeaders(c), base: base}
+}
+
+// RoundTrip implements http.RoundTripper. The caller's request is cloned
+// rather than modified, as the RoundTripper contract requires.
+func (t *propagatingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	out := req.Clone(req.Context())
+	for k, v := range t.headers {
+		out.Header[k] = v
+	}
+	return t.base.RoundTrip(out)
+}
@@ -3,22 +3,196 @@ package authkit
 import (
 	"fmt"
 	"net/http"
+	"sort"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
+const debugAuthzKey = "dromos_auth_debug_authz"
+
 // RequireRole returns a Gin middleware that checks if the authenticated user
 // has at least one of the specified roles. Must be applied AFTER AuthN.
 func RequireRole(roles ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if HasAnyRole(c, roles...) {
+		checkRoles(c, roles)
+	}
+}
+
+// RequireRoleFunc is like RequireRole but computes the acceptable roles per
+// request (e.g. from the sensitivity of the document being edited). If f
+// returns no roles the request is denied. Must be applied AFTER AuthN.
+func RequireRoleFunc(f func(*gin.Context) []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		roles := f(c)
+		if len(roles) == 0 {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "insufficient permissions — no role grants access to this resource",
+			})
+			return
+		}
+		checkRoles(c, roles)
+	}
+}
+
+// Require returns a Gin middleware for ad-hoc authorization rules (e.g. "org is
+// on the enterprise plan AND user is admin"). pred reports whether the request
+// is allowed and, when it is not, a reason that is returned in the standard
+// 403 body. Must be applied AFTER AuthN.
+func Require(pred func(*gin.Context) (bool, string)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		allowed, reason := pred(c)
+		if allowed {
 			c.Next()
 			return
 		}
 
+		msg := "insufficient permissions"
+		if reason != "" {
+			msg += " — " + reason
+		}
 		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
-			"error": fmt.Sprintf("insufficient permissions — requires one of: %s", strings.Join(roles, ", ")),
+			"error": msg,
 		})
 	}
 }
+
+// checkRoles continues the chain if the user has one of roles, and otherwise
+// aborts with the standard RequireRole 403 response.
+func checkRoles(c *gin.Context, roles []string) {
+	if HasAnyRole(c, roles...) {
+		c.Next()
+		return
+	}
+
+	body := gin.H{
+		"error": fmt.Sprintf("insufficient permissions — requires one of: %s", strings.Join(roles, ", ")),
+	}
+	if c.GetBool(debugAuthzKey) {
+		body["required_roles"] = roles
+		body["user_roles"] = userRoleNames(c)
+		body["reason"] = WhyDenied(c, roles...)
+	}
+	c.AbortWithStatusJSON(http.StatusForbidden, body)
+}
+
+// WhyDenied explains a HasAnyRole decision for debugging: whether claims were
+// present, which roles were required, and which the user actually had.
+// Returns empty string if the user has at least one of the roles.
+func WhyDenied(c *gin.Context, roles ...string) string {
+	if HasAnyRole(c, roles...) {
+		return ""
+	}
+
+	cl := GetClaims(c)
+	switch {
+	case cl == nil:
+		return "request is not authenticated — no claims present"
+	case len(roles) == 0:
+		return "no acceptable roles were specified"
+	}
+
+	required := strings.Join(roles, ", ")
+	if len(rolesFor(c, cl)) == 0 {
+		if projectID := ProjectID(c); projectID != "" {
+			return fmt.Sprintf("token has no roles for project %q; requires one of: %s", projectID, required)
+		}
+		return fmt.Sprintf("token carries no roles; requires one of: %s", required)
+	}
+
+	// With Config.ScopeRolesToOrg, a required role may be held but granted only
+	// in another org (or the token may lack an org ID claim altogether)
+	if c.GetBool(orgScopedRolesKey) {
+		var elsewhere []string
+		for _, role := range roles {
+			if _, ok := rolesFor(c, cl)[role]; ok {
+				elsewhere = append(elsewhere, role)
+			}
+		}
+		if len(elsewhere) > 0 {
+			held := strings.Join(elsewhere, ", ")
+			if !cl.HasOrg() {
+				return fmt.Sprintf("roles [%s] are granted, but the token has no org ID claim to scope them to; requires one of: %s",
+					held, required)
+			}
+			return fmt.Sprintf("roles [%s] are granted only in other organizations, not the active org %q; requires one of: %s",
+				held, cl.OrgID, required)
+		}
+	}
+
+	return fmt.Sprintf("user has roles [%s]; requires one of: %s",
+		strings.Join(userRoleNames(c), ", "), required)
+}
+
+// userRoleNames returns the sorted role names that role checks accept for
+// this request, honoring UseProject and Config.ScopeRolesToOrg. Used to build
+// DebugAuthz responses and WhyDenied explanations.
+func userRoleNames(c *gin.Context) []string {
+	names := []string{}
+	cl := GetClaims(c)
+	if cl == nil {
+		return names
+	}
+	for role := range rolesFor(c, cl) {
+		if HasRole(c, role) {
+			names = append(names, role)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RequireClaim returns a Gin middleware that checks the validated token carries
+// the given claim. If wantValue is non-empty, the claim must also equal one of
+// those values (or, for array claims, contain one). Must be applied AFTER AuthN.
+func RequireClaim(key string, wantValue ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		v, ok := Claim(c, key)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": fmt.Sprintf("insufficient permissions — missing required claim %q", key),
+			})
+			return
+		}
+
+		if len(wantValue) > 0 && !claimMatches(v, wantValue) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": fmt.Sprintf("insufficient permissions — claim %q must be one of: %s", key, strings.Join(wantValue, ", ")),
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// claimMatches reports whether a claim value equals one of the wanted values.
+// Array claims match if any element does; scalars are compared by their
+// string form so numbers and booleans can be asserted too.
+func claimMatches(v interface{}, want []string) bool {
+	if arr, ok := v.([]interface{}); ok {
+		for _, el := range arr {
+			if claimMatches(el, want) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var got string
+	switch val := v.(type) {
+	case string:
+		got = val
+	case float64, bool:
+		got = fmt.Sprint(val)
+	default:
+		return false
+	}
+
+	for _, w := range want {
+		if got == w {
+			return true
+		}
+	}
+	return false
+}
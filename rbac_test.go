@@ -0,0 +1,116 @@
+package authkit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestScopeRolesToOrg(t *testing.T) {
+	const rolesClaim = "urn:zitadel:iam:org:project:roles"
+	const orgClaim = "urn:zitadel:iam:org:id"
+	adminIn := func(orgs ...string) map[string]interface{} {
+		grants := map[string]interface{}{}
+		for _, org := range orgs {
+			grants[org] = org + ".example.com"
+		}
+		return map[string]interface{}{"admin": grants}
+	}
+
+	tests := []struct {
+		name     string
+		claims   jwt.MapClaims
+		enrich   func(*gin.Context, *Claims)
+		wantCode int
+	}{
+		{
+			name:     "single org",
+			claims:   jwt.MapClaims{orgClaim: "org-a", rolesClaim: adminIn("org-a")},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "multi org, granted in active org",
+			claims:   jwt.MapClaims{orgClaim: "org-b", rolesClaim: adminIn("org-a", "org-b")},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "multi org, granted only elsewhere",
+			claims:   jwt.MapClaims{orgClaim: "org-a", rolesClaim: adminIn("org-b")},
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "missing org claim",
+			claims:   jwt.MapClaims{rolesClaim: adminIn("org-a")},
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "missing org claim, enrich confirms inferred org",
+			claims:   jwt.MapClaims{rolesClaim: adminIn("org-a")},
+			enrich:   func(_ *gin.Context, cl *Claims) { cl.SetOrgID(cl.OrgID) },
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "missing org claim, enrich sets org",
+			claims:   jwt.MapClaims{rolesClaim: adminIn("org-a", "org-b")},
+			enrich:   func(_ *gin.Context, cl *Claims) { cl.OrgID = "org-b" },
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "missing org claim, enrich leaves org alone",
+			claims:   jwt.MapClaims{rolesClaim: adminIn("org-a")},
+			enrich:   func(c *gin.Context, _ *Claims) { c.Set("tenant", "t-1") },
+			wantCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig(t)
+			cfg.ScopeRolesToOrg = true
+			cfg.EnrichClaims = tt.enrich
+
+			r := gin.New()
+			r.Use(AuthN(cfg))
+			r.GET("/", RequireRole("admin"), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+			req.Header.Set("Authorization", "Bearer "+signTestToken(t, tt.claims))
+
+			w := serve(r, req)
+			if w.Code != tt.wantCode {
+				t.Errorf("status = %d, want %d (body %s)", w.Code, tt.wantCode, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestRequireOrg(t *testing.T) {
+	roles := map[string]interface{}{"admin": map[string]interface{}{"org-a": "a.example.com"}}
+	tests := []struct {
+		name     string
+		claims   jwt.MapClaims
+		wantCode int
+	}{
+		{name: "allowed org claim", claims: jwt.MapClaims{"urn:zitadel:iam:org:id": "org-a"}, wantCode: http.StatusOK},
+		{name: "other org claim", claims: jwt.MapClaims{"urn:zitadel:iam:org:id": "org-b"}, wantCode: http.StatusForbidden},
+		{name: "org inferred from roles", claims: jwt.MapClaims{"urn:zitadel:iam:org:project:roles": roles}, wantCode: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(AuthN(testConfig(t)))
+			r.GET("/", RequireOrg("org-a"), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+			req.Header.Set("Authorization", "Bearer "+signTestToken(t, tt.claims))
+
+			if w := serve(r, req); w.Code != tt.wantCode {
+				t.Errorf("status = %d, want %d (body %s)", w.Code, tt.wantCode, w.Body.String())
+			}
+		})
+	}
+}
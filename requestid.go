@@ -0,0 +1,48 @@
+package authkit
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultRequestIDHeader is the header AuthN reads the request's correlation
+// ID from unless Config.RequestIDHeader overrides it.
+const DefaultRequestIDHeader = "X-Request-ID"
+
+const requestIDKey = "dromos_auth_request_id"
+
+// maxRequestIDLen bounds client-supplied request IDs; longer ones are replaced.
+const maxRequestIDLen = 128
+
+// RequestID returns the correlation ID AuthN recorded for this request: the
+// value of the configured request ID header, or a generated one if the client
+// sent none or an unusable one. Returns empty string if AuthN has not run.
+func RequestID(c *gin.Context) string {
+	return c.GetString(requestIDKey)
+}
+
+// validRequestID reports whether a client-supplied ID is safe to log as is:
+// non-empty, at most maxRequestIDLen bytes, and printable ASCII without
+// spaces, so it cannot inject line breaks or forge log entries.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID returns a random 128-bit hex ID.
+func newRequestID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,82 @@
+package authkit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var errTokenRevoked = errors.New("token revoked")
+
+// RevocationChecker reports whether a token has been revoked before its
+// expiry, by its "jti" claim. Set it on Config.RevocationChecker to honor
+// force-revoked tokens; tokens without a jti are never considered revoked.
+type RevocationChecker interface {
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+// revocationSweepSize is the entry count above which Revoke sweeps out
+// entries whose tokens have expired anyway.
+const revocationSweepSize = 10000
+
+// MemoryRevocationList is an in-memory RevocationChecker. Each revoked jti is
+// remembered only until the time passed to Revoke (normally the token's
+// expiry), after which the token is rejected by its exp claim regardless and
+// the entry can be dropped. It is safe for concurrent use.
+type MemoryRevocationList struct {
+	mu      sync.Mutex
+	entries map[string]time.Time
+}
+
+// NewMemoryRevocationList creates an empty MemoryRevocationList.
+func NewMemoryRevocationList() *MemoryRevocationList {
+	return &MemoryRevocationList{entries: make(map[string]time.Time)}
+}
+
+// Revoke marks jti as revoked until the given time, typically the token's
+// Claims.ExpiresAt.
+func (m *MemoryRevocationList) Revoke(jti string, until time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.entries) >= revocationSweepSize {
+		now := time.Now()
+		for k, exp := range m.entries {
+			if now.After(exp) {
+				delete(m.entries, k)
+			}
+		}
+	}
+	m.entries[jti] = until
+}
+
+// IsRevoked implements RevocationChecker.
+func (m *MemoryRevocationList) IsRevoked(_ context.Context, jti string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	until, ok := m.entries[jti]
+	if !ok {
+		return false, nil
+	}
+	if time.Now().After(until) {
+		delete(m.entries, jti)
+		return false, nil
+	}
+	return true, nil
+}
+
+// checkRevoked consults cfg.RevocationChecker for the token's jti.
+func checkRevoked(ctx context.Context, cfg Config, claims *Claims) error {
+	if cfg.RevocationChecker == nil || claims.JTI == "" {
+		return nil
+	}
+	revoked, err := cfg.RevocationChecker.IsRevoked(ctx, claims.JTI)
+	if err != nil {
+		return fmt.Errorf("revocation check failed: %w", err)
+	}
+	if revoked {
+		return errTokenRevoked
+	}
+	return nil
+}
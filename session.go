@@ -0,0 +1,179 @@
+package authkit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// TokenMode selects the kind of credential AuthN accepts.
+type TokenMode int
+
+const (
+	// TokenModeJWT validates OIDC JWT access tokens against the issuer's JWKS.
+	// This is the default.
+	TokenModeJWT TokenMode = iota
+
+	// TokenModeSession validates Zitadel v2 session tokens against the Session
+	// API using Config.ServiceToken. Clients send the session token as the
+	// bearer token and the session ID in the SessionIDHeader header.
+	TokenModeSession
+)
+
+// DefaultSessionIDHeader is the header carrying the session ID in
+// TokenModeSession when Config.SessionIDHeader is empty.
+const DefaultSessionIDHeader = "X-Zitadel-Session-Id"
+
+var (
+	errMissingSessionID = errors.New("missing session ID")
+	errInvalidSession   = errors.New("invalid session")
+	errSessionExpired   = errors.New("session expired")
+)
+
+// sessionFactor is a credential check recorded on a session. A zero
+// VerifiedAt means the factor was never checked.
+type sessionFactor struct {
+	VerifiedAt time.Time `json:"verifiedAt"`
+}
+
+// sessionResponse is the subset of the v2 GetSession response we map to Claims.
+type sessionResponse struct {
+	Session struct {
+		ID             string    `json:"id"`
+		ExpirationDate time.Time `json:"expirationDate"`
+		Factors        struct {
+			User struct {
+				ID             string `json:"id"`
+				OrganizationID string `json:"organizationId"`
+			} `json:"user"`
+			Password *sessionFactor `json:"password"`
+			WebAuthN *struct {
+				sessionFactor
+				UserVerified bool `json:"userVerified"`
+			} `json:"webAuthN"`
+			Intent   *sessionFactor `json:"intent"`
+			TOTP     *sessionFactor `json:"totp"`
+			OTPSMS   *sessionFactor `json:"otpSms"`
+			OTPEmail *sessionFactor `json:"otpEmail"`
+		} `json:"factors"`
+	} `json:"session"`
+}
+
+// verified reports whether f was checked.
+func (f *sessionFactor) verified() bool {
+	return f != nil && !f.VerifiedAt.IsZero()
+}
+
+// amr maps the session's verified credential factors to RFC 8176 "amr"
+// values. "mfa" is added when more than one factor was checked or a passkey
+// verified the user. An empty result means the session only identifies a
+// user, which is not an authentication.
+func (r *sessionResponse) amr() []string {
+	f := r.Session.Factors
+	var amr []string
+	factors := 0
+	add := func(verified bool, methods ...string) {
+		if !verified {
+			return
+		}
+		factors++
+		for _, m := range methods {
+			if !slices.Contains(amr, m) {
+				amr = append(amr, m)
+			}
+		}
+	}
+
+	passkey := f.WebAuthN != nil && f.WebAuthN.verified()
+	add(f.Password.verified(), "pwd")
+	add(passkey, "hwk")
+	add(f.Intent.verified(), "fed")
+	add(f.TOTP.verified(), "otp")
+	add(f.OTPSMS.verified(), "otp", "sms")
+	add(f.OTPEmail.verified(), "otp")
+
+	if factors > 1 || (passkey && f.WebAuthN.UserVerified) {
+		amr = append(amr, "mfa")
+	}
+	return amr
+}
+
+// sessionValidator checks Zitadel v2 session tokens via the Session API.
+type sessionValidator struct {
+	baseURL      string
+	serviceToken string
+	httpClient   *http.Client
+}
+
+func newSessionValidator(cfg Config) *sessionValidator {
+	return &sessionValidator{
+		baseURL:      strings.TrimSuffix(cfg.IssuerURL, "/"),
+		serviceToken: cfg.ServiceToken,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// validate looks up the session with its token and maps the verified user
+// factor to Claims, with AMR taken from the verified credential factors.
+// Sessions that only identify a user (no password, passkey, IdP or OTP
+// check) are rejected. Session tokens carry no roles, so Claims.Roles is empty.
+func (s *sessionValidator) validate(ctx context.Context, sessionID, sessionToken string) (*Claims, error) {
+	if sessionID == "" {
+		return nil, errMissingSessionID
+	}
+
+	u := s.baseURL + "/v2/sessions/" + url.PathEscape(sessionID) +
+		"?sessionToken=" + url.QueryEscape(sessionToken)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build session request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+s.serviceToken)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("session lookup failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: session API returned status %d", errInvalidSession, resp.StatusCode)
+	}
+
+	r, err := responseBody(resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session: %w", err)
+	}
+	var body sessionResponse
+	if err := json.NewDecoder(r).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode session: %w", err)
+	}
+
+	user := body.Session.Factors.User
+	if user.ID == "" {
+		return nil, fmt.Errorf("%w: session has no verified user", errInvalidSession)
+	}
+	amr := body.amr()
+	if len(amr) == 0 {
+		return nil, fmt.Errorf("%w: session has no verified credential", errInvalidSession)
+	}
+	if exp := body.Session.ExpirationDate; !exp.IsZero() && time.Now().After(exp) {
+		return nil, errSessionExpired
+	}
+
+	return &Claims{
+		Sub:       user.ID,
+		OrgID:     user.OrganizationID,
+		AMR:       amr,
+		ExpiresAt: body.Session.ExpirationDate,
+	}, nil
+}
@@ -0,0 +1,109 @@
+package authkit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSessionFactors(t *testing.T) {
+	tests := []struct {
+		name     string
+		factors  string
+		wantCode int
+		wantAMR  []string
+	}{
+		{
+			name:     "user only",
+			factors:  `{"user":{"id":"user-1","verifiedAt":"2024-01-01T00:00:00Z"}}`,
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "unchecked password",
+			factors:  `{"user":{"id":"user-1"},"password":{}}`,
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "password",
+			factors:  `{"user":{"id":"user-1"},"password":{"verifiedAt":"2024-01-01T00:00:00Z"}}`,
+			wantCode: http.StatusOK,
+			wantAMR:  []string{"pwd"},
+		},
+		{
+			name:     "password and totp",
+			factors:  `{"user":{"id":"user-1"},"password":{"verifiedAt":"2024-01-01T00:00:00Z"},"totp":{"verifiedAt":"2024-01-01T00:00:00Z"}}`,
+			wantCode: http.StatusOK,
+			wantAMR:  []string{"pwd", "otp", "mfa"},
+		},
+		{
+			name:     "user-verified passkey",
+			factors:  `{"user":{"id":"user-1"},"webAuthN":{"verifiedAt":"2024-01-01T00:00:00Z","userVerified":true}}`,
+			wantCode: http.StatusOK,
+			wantAMR:  []string{"hwk", "mfa"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/v2/sessions/session-1" || r.URL.Query().Get("sessionToken") != "session-token" {
+					http.NotFound(w, r)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{"session":{"id":"session-1","factors":` + tt.factors + `}}`))
+			}))
+			defer api.Close()
+
+			var amr []string
+			r := gin.New()
+			r.Use(AuthN(Config{
+				IssuerURL:    api.URL,
+				TokenMode:    TokenModeSession,
+				ServiceToken: "service-token",
+			}))
+			r.GET("/", func(c *gin.Context) {
+				amr = GetClaims(c).AMR
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+			req.Header.Set("Authorization", "Bearer session-token")
+			req.Header.Set(DefaultSessionIDHeader, "session-1")
+
+			w := serve(r, req)
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d (body %s)", w.Code, tt.wantCode, w.Body.String())
+			}
+			if tt.wantCode == http.StatusOK && !slices.Equal(amr, tt.wantAMR) {
+				t.Errorf("AMR = %v, want %v", amr, tt.wantAMR)
+			}
+		})
+	}
+}
+
+func TestSessionModeRejectsAudience(t *testing.T) {
+	base := Config{IssuerURL: testIssuer, TokenMode: TokenModeSession, ServiceToken: "service-token"}
+	if err := base.Validate(); err != nil {
+		t.Fatalf("Validate() = %v, want nil", err)
+	}
+
+	tests := map[string]func(*Config){
+		"Audience":            func(c *Config) { c.Audience = "api" },
+		"AdditionalAudiences": func(c *Config) { c.AdditionalAudiences = []string{"partner"} },
+		"RequireAudience":     func(c *Config) { c.Audience = "api"; c.RequireAudience = true },
+		"AudienceMatchExact":  func(c *Config) { c.AudienceMatchMode = AudienceMatchExact },
+	}
+	for name, set := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg := base
+			set(&cfg)
+			if err := cfg.Validate(); err == nil {
+				t.Error("Validate() = nil, want error")
+			}
+		})
+	}
+}
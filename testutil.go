@@ -0,0 +1,11 @@
+package authkit
+
+import "github.com/gin-gonic/gin"
+
+// InjectClaims marks c as authenticated with cl, as AuthN would, so handler
+// unit tests can exercise UserID, HasRole, RequireRole and friends without
+// minting a JWT. It must not be used to bypass AuthN in production code.
+// authkittest.NewTestContext builds a ready-made context around it.
+func InjectClaims(c *gin.Context, cl *Claims) {
+	SetClaims(c, cl)
+}
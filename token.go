@@ -0,0 +1,87 @@
+package authkit
+
+import (
+	"net/http"
+	"strings"
+)
+
+// tokenSources lists where TokenFromRequest looks for a bearer token.
+type tokenSources struct {
+	headers    []string
+	queryParam string
+	cookie     string
+}
+
+// TokenOption customizes where TokenFromRequest looks for the token.
+type TokenOption func(*tokenSources)
+
+// WithTokenHeaders sets the headers checked, in order, for a "Bearer <token>"
+// value. The default is just "Authorization".
+func WithTokenHeaders(names ...string) TokenOption {
+	return func(s *tokenSources) {
+		s.headers = names
+	}
+}
+
+// WithTokenQueryParam sets the query parameter used as a fallback (default
+// "token"). Pass an empty name to disable the query-param fallback.
+func WithTokenQueryParam(name string) TokenOption {
+	return func(s *tokenSources) {
+		s.queryParam = name
+	}
+}
+
+// WithTokenCookie also reads the raw token from the named cookie, checked
+// after the headers and before the query parameter. Disabled by default.
+func WithTokenCookie(name string) TokenOption {
+	return func(s *tokenSources) {
+		s.cookie = name
+	}
+}
+
+// TokenFromRequest extracts the bearer token from a standard *http.Request
+// using the same rules as AuthN: the Authorization header first, then (if
+// configured) a cookie, then the "token" query parameter for WebSocket
+// upgrades. Returns empty string if no token is found.
+func TokenFromRequest(r *http.Request, opts ...TokenOption) string {
+	src := tokenSources{
+		headers:    []string{"Authorization"},
+		queryParam: "token",
+	}
+	for _, opt := range opts {
+		opt(&src)
+	}
+
+	for _, name := range src.headers {
+		if token := parseBearer(r.Header.Get(name)); token != "" {
+			return token
+		}
+	}
+
+	if src.cookie != "" {
+		if ck, err := r.Cookie(src.cookie); err == nil && ck.Value != "" {
+			return ck.Value
+		}
+	}
+
+	// Fallback: query parameter (for WebSocket connections where browsers
+	// cannot set custom headers on the upgrade request)
+	if src.queryParam != "" {
+		if token := r.URL.Query().Get(src.queryParam); token != "" {
+			return token
+		}
+	}
+
+	return ""
+}
+
+// parseBearer returns the token from a "Bearer <token>" header value. The
+// scheme is matched case-insensitively (RFC 7235) and surrounding whitespace
+// is ignored. Returns empty string for any other scheme.
+func parseBearer(header string) string {
+	scheme, token, ok := strings.Cut(strings.TrimSpace(header), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
@@ -0,0 +1,30 @@
+package authkit
+
+import "testing"
+
+func TestParseBearer(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   string
+	}{
+		{name: "canonical", header: "Bearer abc.def.ghi", want: "abc.def.ghi"},
+		{name: "lower case", header: "bearer abc.def.ghi", want: "abc.def.ghi"},
+		{name: "upper case", header: "BEARER abc.def.ghi", want: "abc.def.ghi"},
+		{name: "surrounding whitespace", header: "  Bearer abc.def.ghi  ", want: "abc.def.ghi"},
+		{name: "extra spaces after scheme", header: "Bearer    abc.def.ghi", want: "abc.def.ghi"},
+		{name: "other scheme", header: "Basic x", want: ""},
+		{name: "bare scheme", header: "Bearer", want: ""},
+		{name: "scheme with only whitespace", header: "Bearer   ", want: ""},
+		{name: "token without scheme", header: "abc.def.ghi", want: ""},
+		{name: "empty", header: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseBearer(tt.header); got != tt.want {
+				t.Errorf("parseBearer(%q) = %q, want %q", tt.header, got, tt.want)
+			}
+		})
+	}
+}
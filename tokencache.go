@@ -0,0 +1,105 @@
+package authkit
+
+import (
+	"crypto/sha256"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	cacheHitKey  = "dromos_auth_cache_hit"
+	freshAuthKey = "dromos_auth_fresh_auth"
+)
+
+// tokenCacheSweepSize is the entry count above which put sweeps out expired
+// entries, bounding memory without a background goroutine.
+const tokenCacheSweepSize = 10000
+
+// tokenCacheEntry is a validated token's claims and when they stop being reusable.
+type tokenCacheEntry struct {
+	claims    *Claims
+	expiresAt time.Time
+}
+
+// tokenCache remembers recently validated JWTs so repeat requests with the
+// same token skip signature verification. Keys are SHA-256 digests, so raw
+// tokens are never held in memory.
+type tokenCache struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[[sha256.Size]byte]tokenCacheEntry
+}
+
+func newTokenCache(ttl time.Duration) *tokenCache {
+	return &tokenCache{
+		ttl:     ttl,
+		entries: make(map[[sha256.Size]byte]tokenCacheEntry),
+	}
+}
+
+// get returns a copy of the cached claims for tokenStr, if still fresh.
+func (t *tokenCache) get(tokenStr string) (*Claims, bool) {
+	key := sha256.Sum256([]byte(tokenStr))
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entry, ok := t.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(t.entries, key)
+		return nil, false
+	}
+
+	cl := *entry.claims
+	return &cl, true
+}
+
+// put caches claims for tokenStr until the TTL elapses or the token expires,
+// whichever comes first.
+func (t *tokenCache) put(tokenStr string, claims *Claims) {
+	expiresAt := time.Now().Add(t.ttl)
+	if !claims.ExpiresAt.IsZero() && claims.ExpiresAt.Before(expiresAt) {
+		expiresAt = claims.ExpiresAt
+	}
+
+	cl := *claims
+	key := sha256.Sum256([]byte(tokenStr))
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.entries) >= tokenCacheSweepSize {
+		now := time.Now()
+		for k, e := range t.entries {
+			if now.After(e.expiresAt) {
+				delete(t.entries, k)
+			}
+		}
+	}
+	t.entries[key] = tokenCacheEntry{claims: &cl, expiresAt: expiresAt}
+}
+
+// WasCacheHit reports whether AuthN served this request's claims from the
+// validated-token cache (Config.TokenCacheTTL) instead of a full verification.
+func WasCacheHit(c *gin.Context) bool {
+	return c.GetBool(cacheHitKey)
+}
+
+// ForceFreshAuth returns a Gin middleware that fully re-validates the token
+// (signature, claims and, if configured, revocation) when AuthN served it from
+// the validated-token cache, so sensitive routes stay strict while others
+// benefit from Config.TokenCacheTTL. This must be applied AFTER AuthN; it is a
+// no-op when the cache is disabled or missed.
+func ForceFreshAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if val, exists := c.Get(freshAuthKey); exists {
+			if revalidate, ok := val.(func(*gin.Context) bool); ok && !revalidate(c) {
+				return
+			}
+		}
+		c.Next()
+	}
+}
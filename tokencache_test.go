@@ -0,0 +1,148 @@
+package authkit
+
+import (
+	"crypto"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// cachedRequest sends tok to path on r and returns the recorder.
+func cachedRequest(r http.Handler, path, tok string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, http.NoBody)
+	req.Header.Set("Authorization", "Bearer "+tok)
+	return serve(r, req)
+}
+
+func TestTokenCacheRevocation(t *testing.T) {
+	revoked := NewMemoryRevocationList()
+	cfg := testConfig(t)
+	cfg.TokenCacheTTL = time.Minute
+	cfg.RevocationChecker = revoked
+
+	var hit bool
+	r := gin.New()
+	r.Use(AuthN(cfg))
+	r.GET("/", func(c *gin.Context) {
+		hit = WasCacheHit(c)
+		c.Status(http.StatusOK)
+	})
+
+	tok := signTestToken(t, jwt.MapClaims{"jti": "token-1"})
+	for i, wantHit := range []bool{false, true} {
+		if w := cachedRequest(r, "/", tok); w.Code != http.StatusOK {
+			t.Fatalf("request %d: status = %d, want 200", i+1, w.Code)
+		}
+		if hit != wantHit {
+			t.Fatalf("request %d: WasCacheHit = %v, want %v", i+1, hit, wantHit)
+		}
+	}
+
+	revoked.Revoke("token-1", time.Now().Add(time.Hour))
+	w := cachedRequest(r, "/", tok)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("after revocation: status = %d, want 401", w.Code)
+	}
+	if code := errorCode(t, w); code != ErrCodeTokenRevoked {
+		t.Errorf("error_code = %q, want %q", code, ErrCodeTokenRevoked)
+	}
+}
+
+func TestTokenCacheExpiresWithToken(t *testing.T) {
+	cache := newTokenCache(time.Hour)
+
+	exp := time.Now().Add(time.Minute)
+	cache.put("live", &Claims{ExpiresAt: exp})
+	for _, e := range cache.entries {
+		if !e.expiresAt.Equal(exp) {
+			t.Errorf("entry expires at %v, want token exp %v", e.expiresAt, exp)
+		}
+	}
+	if _, ok := cache.get("live"); !ok {
+		t.Error("get(live) missed before exp")
+	}
+
+	cache.put("expired", &Claims{ExpiresAt: time.Now().Add(-time.Second)})
+	if _, ok := cache.get("expired"); ok {
+		t.Error("get(expired) hit after the token's exp despite the longer TTL")
+	}
+}
+
+func TestForceFreshAuth(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.TokenCacheTTL = time.Minute
+
+	var hit bool
+	handler := func(c *gin.Context) {
+		hit = WasCacheHit(c)
+		c.Status(http.StatusOK)
+	}
+	r := gin.New()
+	r.Use(AuthN(cfg))
+	r.GET("/", handler)
+	r.GET("/fresh", ForceFreshAuth(), handler)
+
+	tok := signTestToken(t, nil)
+	cachedRequest(r, "/", tok)
+
+	if w := cachedRequest(r, "/", tok); w.Code != http.StatusOK || !hit {
+		t.Fatalf("GET /: status = %d, WasCacheHit = %v; want 200 from the cache", w.Code, hit)
+	}
+	if w := cachedRequest(r, "/fresh", tok); w.Code != http.StatusOK || hit {
+		t.Fatalf("GET /fresh: status = %d, WasCacheHit = %v; want 200 re-verified", w.Code, hit)
+	}
+}
+
+// toggleKeys serves the test signing key until failing is set.
+type toggleKeys struct {
+	fixedKeys
+	failing atomic.Bool
+}
+
+func (k *toggleKeys) GetKey(kid string) (crypto.PublicKey, error) {
+	if k.failing.Load() {
+		return nil, errors.New("key unavailable")
+	}
+	return k.fixedKeys.GetKey(kid)
+}
+
+func TestForceFreshAuthReportOnly(t *testing.T) {
+	keys := &toggleKeys{fixedKeys: fixedKeys{key: &testSigningKey(t).PublicKey}}
+	cfg := testConfig(t)
+	cfg.Keys = keys
+	cfg.TokenCacheTTL = time.Minute
+	cfg.ReportOnly = true
+
+	var claims *Claims
+	var authErr error
+	r := gin.New()
+	r.Use(AuthN(cfg))
+	r.GET("/fresh", ForceFreshAuth(), func(c *gin.Context) {
+		claims, authErr = GetClaims(c), AuthError(c)
+		c.Status(http.StatusOK)
+	})
+
+	tok := signTestToken(t, nil)
+	if w := cachedRequest(r, "/fresh", tok); w.Code != http.StatusOK || claims == nil {
+		t.Fatalf("first request: status = %d, claims = %v; want 200 authenticated", w.Code, claims)
+	}
+
+	// The cache still holds the token, but re-verification now fails
+	keys.failing.Store(true)
+	w := cachedRequest(r, "/fresh", tok)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 in ReportOnly", w.Code)
+	}
+	if claims != nil {
+		t.Errorf("GetClaims = %+v, want nil after failed re-verification", claims)
+	}
+	if authErr == nil {
+		t.Error("AuthError = nil, want the re-verification error")
+	}
+}